@@ -0,0 +1,109 @@
+// Package bench measures the cost of unpacking a payload, so that the impact
+// of options such as parallelism, strictness or an alternative Codec can be
+// quantified programmatically using representative payloads.
+package bench
+
+import (
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/gford1000-go/unpack"
+)
+
+// minDuration is the minimum total time spent unpacking the payload
+// when measuring it, so that the measurements are stable
+const minDuration = 100 * time.Millisecond
+
+// Result describes the cost of unpacking a payload
+type Result struct {
+	// Items is the number of Unpackables in the payload
+	Items int
+	// Runs is the number of times the payload was unpacked
+	Runs int
+	// NsPerRun is the average time taken to unpack the payload
+	NsPerRun float64
+	// NsPerItem is the average time taken per Unpackable
+	NsPerItem float64
+	// AllocsPerItem is the average number of heap allocations per Unpackable
+	AllocsPerItem float64
+	// BytesPerItem is the average number of bytes allocated per Unpackable
+	BytesPerItem float64
+}
+
+// RunProfile unpacks the payload repeatedly using the options, returning the
+// average costs.  An error is returned if the payload cannot be unpacked.
+func RunProfile[F unpack.UnpackableFactory](payload []byte, fact F, opts ...unpack.Option) (Result, error) {
+
+	items, err := unpack.Unpack(payload, fact, opts...)
+	if err != nil {
+		return Result{}, err
+	}
+
+	var (
+		runs    int
+		elapsed time.Duration
+		before  runtime.MemStats
+		after   runtime.MemStats
+	)
+
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	for n := 1; elapsed < minDuration; n *= 2 {
+		start := time.Now()
+		for i := 0; i < n; i++ {
+			if _, err := unpack.Unpack(payload, fact, opts...); err != nil {
+				return Result{}, err
+			}
+		}
+		elapsed += time.Since(start)
+		runs += n
+	}
+
+	runtime.ReadMemStats(&after)
+
+	r := Result{
+		Items:    len(items),
+		Runs:     runs,
+		NsPerRun: float64(elapsed.Nanoseconds()) / float64(runs),
+	}
+
+	if per := float64(runs * len(items)); per > 0 {
+		r.NsPerItem = float64(elapsed.Nanoseconds()) / per
+		r.AllocsPerItem = float64(after.Mallocs-before.Mallocs) / per
+		r.BytesPerItem = float64(after.TotalAlloc-before.TotalAlloc) / per
+	}
+
+	return r, nil
+}
+
+// Comparison is the Result of unpacking a payload with a named set of options
+type Comparison struct {
+	Name string
+	Result
+}
+
+// Compare profiles the payload with each named set of options, returning
+// the Results in ascending order of NsPerRun
+func Compare[F unpack.UnpackableFactory](payload []byte, fact F, variants map[string][]unpack.Option) ([]Comparison, error) {
+
+	ret := make([]Comparison, 0, len(variants))
+
+	for name, opts := range variants {
+		r, err := RunProfile(payload, fact, opts...)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, Comparison{Name: name, Result: r})
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].NsPerRun != ret[j].NsPerRun {
+			return ret[i].NsPerRun < ret[j].NsPerRun
+		}
+		return ret[i].Name < ret[j].Name
+	})
+
+	return ret, nil
+}
@@ -0,0 +1,58 @@
+package bench
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gford1000-go/unpack"
+	"github.com/stretchr/testify/assert"
+)
+
+type quote struct {
+	name  string
+	Close float64 `json:"close"`
+}
+
+func (q *quote) SetName(name string) {
+	q.name = name
+}
+
+type quotef struct{}
+
+func (f quotef) New() unpack.Unpackable {
+	return new(quote)
+}
+
+func TestCompare(t *testing.T) {
+
+	var sb strings.Builder
+	sb.WriteString(`{ "quotes": {`)
+	for i := 0; i < 200; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		fmt.Fprintf(&sb, `"q%03d": { "close": %d.5 }`, i, i)
+	}
+	sb.WriteString(`} }`)
+	b := []byte(sb.String())
+
+	results, err := Compare(b, quotef{}, map[string][]unpack.Option{
+		"default":  nil,
+		"parallel": {unpack.WithParallelism(4)},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(results))
+
+	for _, r := range results {
+		assert.Equal(t, 200, r.Items, r.Name)
+		assert.True(t, r.Runs > 0, r.Name)
+		assert.True(t, r.NsPerItem > 0, r.Name)
+		assert.True(t, r.AllocsPerItem > 0, r.Name)
+		assert.InDelta(t, r.NsPerRun/200, r.NsPerItem, 0.001, r.Name)
+	}
+	assert.True(t, results[0].NsPerRun <= results[1].NsPerRun)
+
+	_, err = RunProfile([]byte(`[]`), quotef{})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,158 @@
+// Package fixtures provides helpers for tests that unpack realistic JSON
+// payloads stored as files, allowing large files to be capped or truncated
+// and sensitive values to be scrubbed before use.
+package fixtures
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/gford1000-go/unpack"
+)
+
+// Option modifies the default behaviour of Load
+type Option func(*options)
+
+type options struct {
+	maxBytes int
+	maxItems int
+	section  string
+	scrub    []string
+}
+
+// WithMaxBytes returns a *unpack.LimitError if the file is larger than n bytes
+func WithMaxBytes(n int) Option {
+	return func(o *options) {
+		o.maxBytes = n
+	}
+}
+
+// WithMaxItems truncates each top level object of the file whose values
+// are all objects (a named map of items) to the first n items, in ascending
+// order of their names (the order Unpack uses by default).  Other objects,
+// such as metadata, are left intact.
+func WithMaxItems(n int) Option {
+	return func(o *options) {
+		o.maxItems = n
+	}
+}
+
+// WithSection restricts WithMaxItems to the named top level object, as
+// unpack.WithSection, for files whose other objects are also named maps
+func WithSection(name string) Option {
+	return func(o *options) {
+		o.section = name
+	}
+}
+
+// WithScrub replaces the values of the named attributes, at any depth,
+// with "REDACTED" (see unpack.RedactAttributes)
+func WithScrub(names ...string) Option {
+	return func(o *options) {
+		o.scrub = append(o.scrub, names...)
+	}
+}
+
+// Load returns the contents of the JSON file, modified by the options
+func Load(path string, opts ...Option) ([]byte, error) {
+
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if o.maxBytes > 0 {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if fi.Size() > int64(o.maxBytes) {
+			return nil, &unpack.LimitError{Limit: "bytes", Max: o.maxBytes, Actual: int(fi.Size())}
+		}
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.maxItems > 0 {
+		if b, err = truncate(b, o.maxItems, o.section); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(o.scrub) > 0 {
+		if b, err = unpack.RedactAttributes(o.scrub...)(b); err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
+
+// MustLoad is as Load, but fails the test if the file cannot be loaded
+func MustLoad(t testing.TB, path string, opts ...Option) []byte {
+	t.Helper()
+
+	b, err := Load(path, opts...)
+	if err != nil {
+		t.Fatalf("loading fixture %s: %v", path, err)
+	}
+	return b
+}
+
+// truncate retains the first n items of the named top level object, or if
+// section is "", of each top level object that is a named map of items
+func truncate(b []byte, n int, section string) ([]byte, error) {
+
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+
+	for name, raw := range m {
+		if section != "" && name != section {
+			continue
+		}
+
+		var items map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil || len(items) <= n {
+			// Not an object (such as the array used by WithOrderFrom), or small enough
+			continue
+		}
+		if section == "" && !isItems(items) {
+			continue
+		}
+
+		names := make([]string, 0, len(items))
+		for name := range items {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names[n:] {
+			delete(items, name)
+		}
+
+		var err error
+		if m[name], err = json.Marshal(items); err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(m)
+}
+
+// isItems reports whether every value of the object is itself an object
+func isItems(items map[string]json.RawMessage) bool {
+	for _, raw := range items {
+		if raw = bytes.TrimSpace(raw); len(raw) == 0 || raw[0] != '{' {
+			return false
+		}
+	}
+	return true
+}
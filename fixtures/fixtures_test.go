@@ -0,0 +1,72 @@
+package fixtures
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gford1000-go/unpack"
+	"github.com/stretchr/testify/assert"
+)
+
+type country struct {
+	name       string
+	Capital    string         `json:"capital"`
+	Population map[string]int `json:"population"`
+}
+
+func (c *country) SetName(name string) {
+	c.name = name
+}
+
+type countryf struct{}
+
+func (f countryf) New() unpack.Unpackable {
+	return new(country)
+}
+
+func TestLoad(t *testing.T) {
+
+	const path = "testdata/countries.json"
+
+	u, err := unpack.Unpack(MustLoad(t, path), countryf{})
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(u))
+
+	u, err = unpack.Unpack(MustLoad(t, path, WithMaxItems(2), WithScrub("capital")), countryf{})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(u))
+	assert.Equal(t, &country{name: "DE", Capital: "REDACTED", Population: map[string]int{"Berlin": 3700000}}, u[0])
+	assert.Equal(t, &country{name: "FR", Capital: "REDACTED", Population: map[string]int{"Paris": 2100000}}, u[1])
+
+	_, err = Load(path, WithMaxBytes(10))
+	var le *unpack.LimitError
+	assert.True(t, errors.As(err, &le))
+
+	_, err = Load("testdata/missing.json")
+	assert.NotNil(t, err)
+}
+
+func TestLoadMetadata(t *testing.T) {
+
+	const path = "testdata/quotes.json"
+
+	// Metadata larger than the limit is not truncated
+	var meta map[string]string
+	b := MustLoad(t, path, WithMaxItems(2))
+	assert.Nil(t, unpack.UnmarshalSection(context.Background(), b, "Meta Data", &meta))
+	assert.Equal(t, 4, len(meta))
+
+	n, err := unpack.Count(context.Background(), b, unpack.WithSection("Time Series (Daily)"))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n)
+
+	// Only the named section is truncated
+	b = MustLoad(t, path, WithMaxItems(1), WithSection("Time Series (Daily)"))
+	assert.Nil(t, unpack.UnmarshalSection(context.Background(), b, "Meta Data", &meta))
+	assert.Equal(t, 4, len(meta))
+
+	n, err = unpack.Count(context.Background(), b, unpack.WithSection("Time Series (Daily)"))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+}
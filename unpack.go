@@ -1,8 +1,15 @@
 package unpack
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
 )
 
 // Unpackable instances provide the ability to assign their name
@@ -16,9 +23,347 @@ type UnpackableFactory interface {
 	New() Unpackable
 }
 
+// NamedUnpackableFactory creates instances of Unpackable that can vary
+// according to the name of the JSON object they will be populated from;
+// for example, to pre-populate defaults or select a particular type.
+// If the factory passed to Unpack implements this interface then
+// NewNamed is used instead of New.
+type NamedUnpackableFactory interface {
+	UnpackableFactory
+	NewNamed(name string) Unpackable
+}
+
+// PolymorphicUnpackableFactory creates instances of Unpackable whose type
+// depends on the content of the JSON object they will be populated from,
+// such as a "type" attribute (see TypeFactory).  If the factory passed to
+// Unpack implements this interface then NewFor is used instead of New or
+// NewNamed.
+type PolymorphicUnpackableFactory interface {
+	UnpackableFactory
+	NewFor(name string, raw json.RawMessage) (Unpackable, error)
+}
+
 // Unpack returns the slice of Unpackable instances within a JSON objects
 // The Unpackable must be a pointer type implementation of the interface.
-func Unpack[F UnpackableFactory](b []byte, fact F) ([]Unpackable, error) {
+// The instances are returned in ascending order of their names, unless
+// another order is specified via the options (see WithOrdering).
+// Options can be provided to modify how each instance is populated.
+func Unpack[F UnpackableFactory](b []byte, fact F, opts ...Option) ([]Unpackable, error) {
+	return UnpackContext(context.Background(), b, fact, opts...)
+}
+
+// UnpackContext is as Unpack, but stops and returns the context's error
+// if the context is cancelled or its deadline passes before completion.
+func UnpackContext[F UnpackableFactory](ctx context.Context, b []byte, fact F, opts ...Option) ([]Unpackable, error) {
+
+	o := newOptions(opts)
+
+	if o.recorder != nil {
+		if err := o.recorder.Record(b); err != nil {
+			return nil, err
+		}
+	}
+
+	p, err := prepare(ctx, b, o)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret = make([]Unpackable, len(p.names))
+
+	newFn := newFunc(fact)
+
+	var (
+		mu       sync.Mutex
+		done     int
+		itemErrs []error
+		deleted  []bool
+		stopped  = len(p.names) // index of the first Unpackable not populated within the budget
+		deadline time.Time
+	)
+
+	if o.continueOnError {
+		itemErrs = make([]error, len(p.names))
+	}
+
+	if o.tombstones {
+		deleted = make([]bool, len(p.names))
+	}
+
+	if o.maxDecodeDuration > 0 {
+		deadline = time.Now().Add(o.maxDecodeDuration)
+	}
+
+	unpack := func(i int) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			mu.Lock()
+			defer mu.Unlock()
+			if i < stopped {
+				stopped = i
+			}
+			return ErrDecodeDurationExceeded
+		}
+
+		if o.limiter != nil {
+			if err := o.limiter.Wait(ctx); err != nil {
+				return err
+			}
+		}
+
+		var (
+			r   Unpackable
+			err error
+		)
+		if o.tombstones && isNull(p.items[p.names[i]]) {
+			deleted[i] = true
+		} else if r, err = populate(newFn, p.names[i], p.merged[p.names[i]], p.items, o); err == nil && o.tombstones && isDeleted(r, o) {
+			deleted[i], r = true, nil
+		}
+		if err != nil && o.errorHandler != nil {
+			err = o.errorHandler(p.names[i], p.items[p.names[i]], err)
+		}
+		if err != nil {
+			if !o.continueOnError {
+				return err
+			}
+			itemErrs[i] = err
+		}
+
+		ret[i] = r
+
+		if o.progressFn != nil {
+			mu.Lock()
+			defer mu.Unlock()
+			done++
+			o.progressFn(done, len(p.names))
+		}
+		return nil
+	}
+
+	// When partial results are permitted, an exceeded budget returns
+	// those Unpackables before the first that was not populated
+	budgetErr := func(err error) error {
+		if err == ErrDecodeDurationExceeded && o.continueOnError {
+			return nil
+		}
+		return err
+	}
+
+	w := workers(b, len(p.names), o)
+
+	if w > 1 {
+		if err := budgetErr(parallel(len(p.names), w, unpack)); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, name := range p.names[:stopped] {
+		if w <= 1 {
+			if err := budgetErr(unpack(i)); err != nil {
+				return nil, err
+			}
+			if i == stopped {
+				break
+			}
+		}
+
+		if o.checkpointFn != nil && (i+1)%o.checkpointEvery == 0 {
+			if err := o.checkpointFn(name); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Always checkpoint the final item
+	if o.checkpointFn != nil && stopped > 0 && stopped%o.checkpointEvery != 0 {
+		if err := o.checkpointFn(p.names[stopped-1]); err != nil {
+			return nil, err
+		}
+	}
+
+	if o.tombstoneFn != nil {
+		for i, name := range p.names[:stopped] {
+			if deleted[i] {
+				o.tombstoneFn(name)
+			}
+		}
+	}
+
+	var items []Unpackable
+	if stopped < len(p.names) {
+		items, err = partial(ret[:stopped], itemErrs[:stopped])
+		errs, _ := err.(Errors)
+		err = append(errs, ErrDecodeDurationExceeded)
+	} else {
+		items, err = partial(ret, itemErrs)
+	}
+
+	if o.sortBy != nil {
+		sort.SliceStable(items, func(i, j int) bool { return o.sortBy(items[i], items[j]) })
+	}
+
+	if p.unknown != nil {
+		err = warn(err, p.unknown)
+	}
+
+	if o.anomalyFn != nil {
+		if a := detectAnomalies(items, o.anomalyFn); a != nil {
+			err = warn(err, a)
+		}
+	}
+
+	return items, err
+}
+
+// warn returns the error to be returned with the Unpackables after adding
+// the warning, which does not prevent the Unpackables being returned
+func warn(err, w error) error {
+	switch errs := err.(type) {
+	case nil:
+		return w
+	case Errors:
+		return append(errs, w)
+	default:
+		return Errors{err, w}
+	}
+}
+
+// join returns err with the errors of other added, flattening an Errors,
+// so that the errors of several unpacks can be returned together
+func join(err, other error) error {
+	if errs, ok := other.(Errors); ok {
+		for _, e := range errs {
+			err = warn(err, e)
+		}
+		return err
+	}
+	if other == nil {
+		return err
+	}
+	return warn(err, other)
+}
+
+// partial returns the Unpackables that were successfully populated,
+// together with an Errors if any could not be populated
+func partial(ret []Unpackable, itemErrs []error) ([]Unpackable, error) {
+	var errs Errors
+	unpackables := make([]Unpackable, 0, len(ret))
+
+	for i, r := range ret {
+		if itemErrs != nil && itemErrs[i] != nil {
+			errs = append(errs, itemErrs[i])
+			continue
+		}
+		if r != nil {
+			unpackables = append(unpackables, r)
+		}
+	}
+
+	if len(errs) > 0 {
+		return unpackables, errs
+	}
+	return unpackables, nil
+}
+
+// newFunc returns the function used to create Unpackables from the factory
+func newFunc(fact UnpackableFactory) func(name string, raw json.RawMessage) (Unpackable, error) {
+	switch f := fact.(type) {
+	case PolymorphicUnpackableFactory:
+		return f.NewFor
+	case NamedUnpackableFactory:
+		return func(name string, _ json.RawMessage) (Unpackable, error) { return f.NewNamed(name), nil }
+	default:
+		return func(string, json.RawMessage) (Unpackable, error) { return fact.New(), nil }
+	}
+}
+
+// isNull reports whether the JSON is null
+func isNull(b json.RawMessage) bool {
+	return string(bytes.TrimSpace(b)) == "null"
+}
+
+// isDeleted reports whether the field of the Unpackable tagged
+// `unpack:",deleted"`, if there is one, is true
+func isDeleted(r Unpackable, o *options) bool {
+	p := cachedPlan(reflect.TypeOf(r), o.tagName)
+	if p.deleted == nil {
+		return false
+	}
+	return fieldValue(reflect.ValueOf(r).Elem(), p.deleted).Bool()
+}
+
+// populate returns a new Unpackable, populated from the JSON object with the
+// name, followed by those of any names merged into it, and then named using
+// the name as transformed and converted by the options
+func populate(newFn func(string, json.RawMessage) (Unpackable, error), name string, merged []string, items map[string]json.RawMessage, o *options) (Unpackable, error) {
+	canonical := o.transformName(name)
+	if o.keyLayout != "" {
+		var err error
+		if canonical, err = o.convertZone(canonical); err != nil {
+			return nil, &UnpackError{Name: name, Err: err}
+		}
+	}
+
+	r, err := newFn(canonical, items[name])
+	if err != nil {
+		return nil, &UnpackError{Name: name, Err: err}
+	}
+
+	if err := setDefaults(r, o); err != nil {
+		return nil, &UnpackError{Name: name, Err: err}
+	}
+
+	for _, n := range append([]string{name}, merged...) {
+		if o.maxDepth > 0 {
+			if d := depth(items[n]); d > o.maxDepth {
+				return nil, &UnpackError{Name: n, Err: &LimitError{Limit: "depth", Max: o.maxDepth, Actual: d}}
+			}
+		}
+
+		b := items[n]
+		if o.maxValueLength > 0 || o.fieldValueLengths != nil {
+			var err error
+			if b, err = limitValues(b, o); err != nil {
+				return nil, newUnpackError(n, err)
+			}
+		}
+
+		if err := decodeItem(b, r, o); err != nil {
+			return nil, newUnpackError(n, err)
+		}
+	}
+	if o.interner != nil {
+		o.interner.walk(reflect.ValueOf(r))
+	}
+	r.SetName(canonical)
+	if on, ok := r.(OriginalNamed); ok {
+		on.SetOriginalName(name)
+	}
+
+	if o.initFn != nil {
+		if err := o.initFn(canonical, r); err != nil {
+			return nil, &UnpackError{Name: name, Err: err}
+		}
+	}
+
+	return r, nil
+}
+
+// prepared holds the result of parsing the JSON object
+type prepared struct {
+	items   map[string]json.RawMessage
+	names   []string
+	merged  map[string][]string
+	unknown error // an *UnknownSectionsError, if they are to be reported with the Unpackables
+}
+
+// prepare parses the JSON object, returning the JSON objects of the Unpackables
+// and their names in the order they are to be returned
+func prepare(ctx context.Context, b []byte, o *options) (*prepared, error) {
 
 	/*
 		The JSON structure should have been of the form:
@@ -36,38 +381,259 @@ func Unpack[F UnpackableFactory](b []byte, fact F) ([]Unpackable, error) {
 
 		Each JSON object is expected to be the same structure
 
+		If WithOrderFrom is used, a second attribute is permitted, whose
+		value is an array of unpackable names in the required order:
+
+		{
+			<order attribute name> : [ "Y", "X", ... ]
+		}
+
 		Exit if the structure is not well formed
 	*/
-	var m map[string]map[string]interface{}
-	if err := json.Unmarshal(b, &m); err != nil {
+	if o.maxBytes > 0 && len(b) > o.maxBytes {
+		return nil, &LimitError{Limit: "bytes", Max: o.maxBytes, Actual: len(b)}
+	}
+
+	m := o.decoded
+	if m == nil {
+		if err := o.codec.Unmarshal(b, &m); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := providerError(m, o); err != nil {
+		return nil, err
+	}
+
+	var order []string
+	if o.orderFrom != "" {
+		raw, ok := m[o.orderFrom]
+		if !ok {
+			return nil, fmt.Errorf("order attribute %q not found", o.orderFrom)
+		}
+		if err := o.codec.Unmarshal(raw, &order); err != nil {
+			return nil, err
+		}
+		delete(m, o.orderFrom)
+	}
+
+	for _, name := range o.skipped {
+		delete(m, name)
+	}
+
+	if err := handleSections(m, o); err != nil {
 		return nil, err
 	}
 
+	var unknown error
+	if o.section != "" {
+		raw, ok := m[o.section]
+		if !ok {
+			return nil, fmt.Errorf("section %q not found", o.section)
+		}
+		for _, name := range o.sectionPath {
+			var inner map[string]json.RawMessage
+			if err := o.codec.Unmarshal(raw, &inner); err != nil {
+				return nil, err
+			}
+			if raw, ok = inner[name]; !ok {
+				return nil, fmt.Errorf("section %q not found", name)
+			}
+		}
+
+		if o.extras != nil {
+			extras := make(map[string]json.RawMessage, len(m)-1)
+			for name, raw := range m {
+				if name != o.section {
+					extras[name] = raw
+				}
+			}
+			o.extras(extras)
+		}
+
+		if len(m) > 1 && o.unknownSections != UnknownSectionIgnore {
+			e := &UnknownSectionsError{}
+			for name := range m {
+				if name != o.section {
+					e.Names = append(e.Names, name)
+				}
+			}
+			sort.Strings(e.Names)
+
+			if o.unknownSections == UnknownSectionError {
+				return nil, e
+			}
+			unknown = e
+		}
+
+		m = map[string]json.RawMessage{o.section: raw}
+	}
+
 	// Should only have a single entry in the outer map
 	if len(m) != 1 {
 		return nil, errors.New("incorrectly formed JSON")
 	}
 
-	var ret = []Unpackable{}
-
-	for _, items := range m {
-		for name, item := range items {
-			b, err := json.Marshal(item) // Not ideal obvs ...
+	var (
+		items map[string]json.RawMessage
+		names []string
+	)
+	for _, raw := range m {
+		if o.maxItems > 0 {
+			n, err := countNames(raw, o.maxItems)
 			if err != nil {
 				return nil, err
 			}
+			if n > o.maxItems {
+				return nil, &LimitError{Limit: "items", Max: o.maxItems, Actual: n}
+			}
+		}
 
-			// ... but easiest way to obtain the byte slice
-			// to parse into actual structure
-			r := fact.New()
-			if err := json.Unmarshal(b, r); err != nil {
+		if o.duplicateNames == DuplicateNameLast && o.ordering != OrderingDocument {
+			if err := o.codec.Unmarshal(raw, &items); err != nil {
 				return nil, err
 			}
-			r.SetName(name)
+			continue
+		}
+
+		var err error
+		if items, names, err = scanItems(raw, o.duplicateNames); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
-			ret = append(ret, r)
+	if o.ordering != OrderingDocument {
+		names = make([]string, 0, len(items))
+		for name := range items {
+			names = append(names, name)
 		}
 	}
+	names, merged, err := arrangeNames(names, func(name string) bool {
+		_, ok := items[name]
+		return ok
+	}, order, o)
+	if err != nil {
+		return nil, err
+	}
+
+	return &prepared{
+		items:   items,
+		names:   names,
+		merged:  merged,
+		unknown: unknown,
+	}, nil
+}
 
-	return ret, nil
+// arrangeNames orders and selects the names of the Unpackables as specified
+// by the options, given whether a name is present and any order read from the
+// attribute named by WithOrderFrom, returning the names merged into each
+func arrangeNames(names []string, has func(name string) bool, order []string, o *options) ([]string, map[string][]string, error) {
+
+	sortNames(names, o)
+
+	if o.timeLayout != "" {
+		if err := sortTimes(names, o.timeLayout, o.timeLocation, o.ordering); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if order != nil {
+		names = orderNames(names, order)
+	}
+
+	if o.filterFn != nil {
+		names = filterNames(names, o.filterFn)
+	}
+
+	if o.namePattern != nil {
+		names = filterNames(names, o.namePattern.MatchString)
+	}
+
+	if o.keys != nil {
+		required := make(map[string]bool, len(o.keys))
+		for _, key := range o.keys {
+			if !has(key) && !o.skipMissingKeys {
+				return nil, nil, fmt.Errorf("%q: %w", key, ErrNameNotFound)
+			}
+			required[key] = true
+		}
+		names = filterNames(names, func(name string) bool { return required[name] })
+	}
+
+	names, merged, err := collideNames(names, o.nameCollisions, o.collisionKey())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if o.resumeAfter != "" {
+		i := indexOf(names, o.resumeAfter)
+		if i < 0 {
+			return nil, nil, fmt.Errorf("resume name %q not found", o.resumeAfter)
+		}
+		names = names[i+1:]
+	}
+
+	if o.offset > 0 {
+		if o.offset < len(names) {
+			names = names[o.offset:]
+		} else {
+			names = names[:0]
+		}
+	}
+
+	if o.limit > 0 && o.limit < len(names) {
+		names = names[:o.limit]
+	}
+
+	if o.selectFn != nil {
+		names = o.selectFn(names)
+	}
+
+	return names, merged, nil
+}
+
+// UnpackIfNewer is as UnpackContext, but first reads the attribute of the
+// top level section meta, such as "3. Last Refreshed" of "Meta Data", and
+// returns ErrNotModified without decoding the Unpackables unless its value
+// is greater than lastRefreshed.  The values are compared as strings, so
+// should be in a form such as RFC 3339 that orders correctly.  The meta
+// section is ignored when locating the Unpackables.  The value is returned
+// so that it can be passed as lastRefreshed to a later call.
+func UnpackIfNewer[F UnpackableFactory](ctx context.Context, b []byte, fact F, meta, attr, lastRefreshed string, opts ...Option) ([]Unpackable, string, error) {
+
+	var m map[string]json.RawMessage
+	if err := UnmarshalSection(ctx, b, meta, &m, opts...); err != nil {
+		return nil, "", err
+	}
+
+	raw, ok := m[attr]
+	if !ok {
+		return nil, "", fmt.Errorf("attribute %q not found in section %q", attr, meta)
+	}
+
+	var refreshed string
+	if err := newOptions(opts).codec.Unmarshal(raw, &refreshed); err != nil {
+		return nil, "", err
+	}
+
+	if refreshed <= lastRefreshed {
+		return nil, refreshed, ErrNotModified
+	}
+
+	u, err := UnpackContext(ctx, b, fact, append(opts[:len(opts):len(opts)], WithSkipSections(meta))...)
+	return u, refreshed, err
+}
+
+// indexOf returns the position of name in names, or -1 if not present
+func indexOf(names []string, name string) int {
+	for i, n := range names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
 }
@@ -0,0 +1,48 @@
+package unpack
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Anomaly describes a suspicious change between consecutive Unpackables,
+// as reported by the function provided to WithAnomalyDetector
+type Anomaly struct {
+	Index int // position of the later of the Unpackables in those returned
+	Err   error
+}
+
+func (a *Anomaly) Error() string {
+	return fmt.Sprintf("anomaly at %d: %v", a.Index, a.Err)
+}
+
+func (a *Anomaly) Unwrap() error {
+	return a.Err
+}
+
+// Anomalies is returned, together with all the Unpackables, when the
+// function provided to WithAnomalyDetector reports one or more anomalies.
+// If WithContinueOnError is also used and Unpackables could not be
+// populated, or other warnings are reported (see WithUnknownSections), the
+// Anomalies is instead the last of the Errors returned.
+type Anomalies []*Anomaly
+
+func (a Anomalies) Error() string {
+	s := make([]string, len(a))
+	for i, err := range a {
+		s[i] = err.Error()
+	}
+	return strings.Join(s, "\n")
+}
+
+// detectAnomalies returns the anomalies that detect reports for each
+// pair of consecutive Unpackables, or nil if there are none
+func detectAnomalies(items []Unpackable, detect func(prev, curr Unpackable) error) Anomalies {
+	var ret Anomalies
+	for i := 1; i < len(items); i++ {
+		if err := detect(items[i-1], items[i]); err != nil {
+			ret = append(ret, &Anomaly{Index: i, Err: err})
+		}
+	}
+	return ret
+}
@@ -0,0 +1,57 @@
+package unpack
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnomalyDetector(t *testing.T) {
+
+	b := []byte(`
+{
+	"history": {
+		"2023-08-18": { "close": 140.5 },
+		"2023-08-21": { "close": 141.25 },
+		"2023-08-22": { "close": 72.0 },
+		"2023-08-23": { "close": 71.5 }
+	}
+}
+	`)
+
+	gap := func(prev, curr *quote) error {
+		if math.Abs(curr.Close-prev.Close)/prev.Close > 0.4 {
+			return fmt.Errorf("close moved from %v to %v", prev.Close, curr.Close)
+		}
+		return nil
+	}
+
+	u, err := Unpack(b, quotef{}, WithAnomalyDetector(gap))
+	assert.Equal(t, 4, len(u))
+
+	var a Anomalies
+	assert.True(t, errors.As(err, &a))
+	assert.Equal(t, 1, len(a))
+	assert.Equal(t, 2, a[0].Index)
+	assert.Equal(t, "2023-08-22", u[a[0].Index].(*quote).name)
+	assert.Equal(t, "anomaly at 2: close moved from 141.25 to 72", err.Error())
+
+	_, err = Unpack(b, quotef{}, WithAnomalyDetector(gap), WithOffset(2))
+	assert.Nil(t, err)
+
+	u, err = Unpack(b, quotef{}, WithAnomalyDetector(gap), WithContinueOnError(), WithInitFn(func(name string, _ Unpackable) error {
+		if name == "2023-08-23" {
+			return errors.New("bad")
+		}
+		return nil
+	}))
+	assert.Equal(t, 3, len(u))
+
+	var errs Errors
+	assert.True(t, errors.As(err, &errs))
+	assert.Equal(t, 2, len(errs))
+	assert.True(t, errors.As(err, &a))
+}
@@ -0,0 +1,43 @@
+package unpack
+
+import "runtime"
+
+const (
+	// autoMinItems and autoMinBytes are the sizes below which the cost of
+	// starting goroutines outweighs the benefit of parallel population
+	autoMinItems = 256
+	autoMinBytes = 64 << 10
+	// autoItemsPerWorker is the minimum number of Unpackables per goroutine
+	autoItemsPerWorker = 128
+)
+
+// WithAutoStrategy chooses how the Unpackables are populated from the size
+// of the JSON object and the number of Unpackables, rather than requiring
+// the options to be tuned for each payload: small payloads are populated
+// sequentially, and large ones in parallel using up to GOMAXPROCS goroutines.
+// It has no effect if WithParallelism is also used.  As with WithParallelism,
+// the UnpackableFactory, Codec and functions passed to other options must be
+// safe for concurrent use.
+func WithAutoStrategy() Option {
+	return func(o *options) {
+		o.auto = true
+	}
+}
+
+// workers returns the number of goroutines used to populate n Unpackables
+// from the JSON object b
+func workers(b []byte, n int, o *options) int {
+	if o.parallelism > 0 || !o.auto {
+		return o.parallelism
+	}
+
+	if n < autoMinItems || len(b) < autoMinBytes {
+		return 1
+	}
+
+	w := n / autoItemsPerWorker
+	if procs := runtime.GOMAXPROCS(0); w > procs {
+		w = procs
+	}
+	return w
+}
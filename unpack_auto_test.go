@@ -0,0 +1,65 @@
+package unpack
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkers(t *testing.T) {
+
+	large := make([]byte, autoMinBytes)
+	procs := runtime.GOMAXPROCS(0)
+
+	min := func(a, b int) int {
+		if a < b {
+			return a
+		}
+		return b
+	}
+
+	type tc struct {
+		b       []byte
+		n       int
+		opts    []Option
+		workers int
+	}
+
+	tests := []tc{
+		{b: large, n: 10000, workers: 0},
+		{b: large, n: 10000, opts: []Option{WithParallelism(3)}, workers: 3},
+		{b: large, n: 10000, opts: []Option{WithAutoStrategy(), WithParallelism(3)}, workers: 3},
+		{b: large, n: 10000, opts: []Option{WithAutoStrategy()}, workers: procs},
+		{b: large, n: 512, opts: []Option{WithAutoStrategy()}, workers: min(4, procs)},
+		{b: large, n: 100, opts: []Option{WithAutoStrategy()}, workers: 1},
+		{b: []byte(`{}`), n: 10000, opts: []Option{WithAutoStrategy()}, workers: 1},
+	}
+
+	for i, test := range tests {
+		assert.Equal(t, test.workers, workers(test.b, test.n, newOptions(test.opts)), "test %d", i)
+	}
+}
+
+func TestUnpackAutoStrategy(t *testing.T) {
+
+	var sb strings.Builder
+	sb.WriteString(`{ "hosts": {`)
+	for i := 0; i < 2000; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		fmt.Fprintf(&sb, `"h%04d": { "ip": "10.0.%d.%d", "level": "low" }`, i, i/256, i%256)
+	}
+	sb.WriteString(`} }`)
+	b := []byte(sb.String())
+
+	seq, err := Unpack(b, hostf{})
+	assert.Nil(t, err)
+
+	auto, err := Unpack(b, hostf{}, WithAutoStrategy())
+	assert.Nil(t, err)
+	assert.Equal(t, seq, auto)
+}
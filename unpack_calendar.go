@@ -0,0 +1,119 @@
+package unpack
+
+import (
+	"errors"
+	"time"
+)
+
+// Calendar describes the days on which date-named Unpackables are expected,
+// such as the trading days of an exchange, so that gaps in a history can be
+// distinguished from weekends and holidays
+type Calendar struct {
+	layout   string
+	weekend  map[time.Weekday]bool
+	holidays map[string]bool
+}
+
+// NewCalendar returns a Calendar for names using the layout (see time.Parse),
+// on which no Unpackables are expected on the days of the weekend, which are
+// Saturday and Sunday if weekend is nil, or on the holidays
+func NewCalendar(layout string, weekend []time.Weekday, holidays ...time.Time) *Calendar {
+
+	if weekend == nil {
+		weekend = []time.Weekday{time.Saturday, time.Sunday}
+	}
+
+	c := &Calendar{
+		layout:   layout,
+		weekend:  make(map[time.Weekday]bool, len(weekend)),
+		holidays: make(map[string]bool, len(holidays)),
+	}
+	for _, d := range weekend {
+		c.weekend[d] = true
+	}
+	for _, h := range holidays {
+		c.holidays[h.Format("2006-01-02")] = true
+	}
+	return c
+}
+
+// errNoExpectedDays is returned when the Calendar has no expected days
+var errNoExpectedDays = errors.New("calendar has no expected days")
+
+// IsExpected reports whether an Unpackable is expected with the name,
+// returning an error if the name cannot be parsed using the layout
+func (c *Calendar) IsExpected(name string) (bool, error) {
+	t, err := time.Parse(c.layout, name)
+	if err != nil {
+		return false, err
+	}
+	return c.expected(t), nil
+}
+
+// NextKey returns the name of the first expected day after that of the name
+func (c *Calendar) NextKey(name string) (string, error) {
+	return c.step(name, 1)
+}
+
+// PrevKey returns the name of the last expected day before that of the name
+func (c *Calendar) PrevKey(name string) (string, error) {
+	return c.step(name, -1)
+}
+
+// MissingKeys returns the names of the expected days between the first and
+// last of the names, in ascending order, that are not among the names
+func (c *Calendar) MissingKeys(names []string) ([]string, error) {
+
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	present := make(map[string]bool, len(names))
+	var first, last time.Time
+	for i, name := range names {
+		t, err := time.Parse(c.layout, name)
+		if err != nil {
+			return nil, err
+		}
+		present[t.Format("2006-01-02")] = true
+		if i == 0 || t.Before(first) {
+			first = t
+		}
+		if i == 0 || t.After(last) {
+			last = t
+		}
+	}
+
+	var ret []string
+	for t := first; !t.After(last); t = t.AddDate(0, 0, 1) {
+		if c.expected(t) && !present[t.Format("2006-01-02")] {
+			ret = append(ret, t.Format(c.layout))
+		}
+	}
+	return ret, nil
+}
+
+// expected reports whether an Unpackable is expected on the day of t
+func (c *Calendar) expected(t time.Time) bool {
+	return !c.weekend[t.Weekday()] && !c.holidays[t.Format("2006-01-02")]
+}
+
+// step returns the name of the nearest expected day in the direction
+func (c *Calendar) step(name string, dir int) (string, error) {
+
+	t, err := time.Parse(c.layout, name)
+	if err != nil {
+		return "", err
+	}
+
+	if len(c.weekend) >= 7 {
+		return "", errNoExpectedDays
+	}
+
+	for {
+		t = t.AddDate(0, 0, dir)
+		if c.expected(t) {
+			return t.Format(c.layout), nil
+		}
+	}
+}
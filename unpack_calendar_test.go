@@ -0,0 +1,49 @@
+package unpack
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendar(t *testing.T) {
+
+	c := NewCalendar("2006-01-02", nil, time.Date(2023, 8, 28, 0, 0, 0, 0, time.UTC))
+
+	ok, err := c.IsExpected("2023-08-25")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	ok, err = c.IsExpected("2023-08-26")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	ok, err = c.IsExpected("2023-08-28")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	_, err = c.IsExpected("25/08/2023")
+	assert.NotNil(t, err)
+
+	next, err := c.NextKey("2023-08-25")
+	assert.Nil(t, err)
+	assert.Equal(t, "2023-08-29", next)
+
+	prev, err := c.PrevKey("2023-08-29")
+	assert.Nil(t, err)
+	assert.Equal(t, "2023-08-25", prev)
+
+	missing, err := c.MissingKeys([]string{"2023-08-31", "2023-08-24", "2023-08-29"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2023-08-25", "2023-08-30"}, missing)
+
+	gulf := NewCalendar("02/01/2006", []time.Weekday{time.Friday, time.Saturday})
+	next, err = gulf.NextKey("24/08/2023")
+	assert.Nil(t, err)
+	assert.Equal(t, "27/08/2023", next)
+
+	never := NewCalendar("2006-01-02", []time.Weekday{0, 1, 2, 3, 4, 5, 6})
+	_, err = never.NextKey("2023-08-25")
+	assert.Equal(t, errNoExpectedDays, err)
+}
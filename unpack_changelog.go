@@ -0,0 +1,191 @@
+package unpack
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Op is the kind of change made to a named JSON object
+type Op string
+
+const (
+	// OpCreate adds a JSON object with a name that is not present
+	OpCreate Op = "create"
+	// OpUpdate replaces the JSON object with a name that is present
+	OpUpdate Op = "update"
+	// OpDelete removes the JSON object with a name that is present
+	OpDelete Op = "delete"
+)
+
+// Change is a single entry of a ChangeLog
+type Change struct {
+	Op    Op              `json:"op"`
+	Name  string          `json:"name"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// ChangeLog records changes made to the named JSON objects of a payload, in
+// the order they are made, so that they can be written as newline delimited
+// JSON and later replayed against the payload.  It is safe for concurrent use.
+type ChangeLog struct {
+	mu      sync.Mutex
+	changes []Change
+}
+
+// Create records the addition of the Unpackable with the name
+func (l *ChangeLog) Create(name string, u Unpackable) error {
+	return l.record(OpCreate, name, u)
+}
+
+// Update records the replacement of the Unpackable with the name
+func (l *ChangeLog) Update(name string, u Unpackable) error {
+	return l.record(OpUpdate, name, u)
+}
+
+// Delete records the removal of the Unpackable with the name
+func (l *ChangeLog) Delete(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.changes = append(l.changes, Change{Op: OpDelete, Name: name})
+}
+
+// record appends the change, holding the Unpackable as JSON so that
+// later changes to the Unpackable do not affect the log
+func (l *ChangeLog) record(op Op, name string, u Unpackable) error {
+	b, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.changes = append(l.changes, Change{Op: op, Name: name, Value: b})
+	return nil
+}
+
+// Changes returns a copy of the changes, in the order they were recorded
+func (l *ChangeLog) Changes() []Change {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]Change(nil), l.changes...)
+}
+
+// WriteTo writes the changes to w as newline delimited JSON, one change per line
+func (l *ChangeLog) WriteTo(w io.Writer) (int64, error) {
+	var n int64
+	for _, c := range l.Changes() {
+		b, err := json.Marshal(c)
+		if err != nil {
+			return n, err
+		}
+		m, err := w.Write(append(b, '\n'))
+		n += int64(m)
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// ReadChangeLog returns the ChangeLog written as newline delimited JSON to r
+func ReadChangeLog(r io.Reader) (*ChangeLog, error) {
+
+	l := &ChangeLog{}
+
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 64*1024*1024)
+	for line := 1; s.Scan(); line++ {
+		if len(s.Bytes()) == 0 {
+			continue
+		}
+
+		var c Change
+		if err := json.Unmarshal(s.Bytes(), &c); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		switch c.Op {
+		case OpCreate, OpUpdate, OpDelete:
+		default:
+			return nil, fmt.Errorf("line %d: unknown op %q", line, c.Op)
+		}
+		l.changes = append(l.changes, c)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// Replay applies the changes, in order, to the named JSON objects of the
+// payload, returning the resulting payload.  An error is returned if a
+// created name is already present, or an updated or deleted name is not.
+// Of the options, only WithOrderFrom, WithSection and WithSkipSections are
+// used, to locate the named JSON objects; other top level attributes are
+// retained.  WithSectionPath is not supported.
+func (l *ChangeLog) Replay(b []byte, opts ...Option) ([]byte, error) {
+
+	o := newOptions(opts)
+
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+
+	if len(o.sectionPath) > 0 {
+		return nil, errors.New("WithSectionPath is not supported")
+	}
+
+	section := o.section
+	if section == "" {
+		for name := range m {
+			if name != o.orderFrom && !o.isSkipped(name) {
+				if section != "" {
+					return nil, errors.New("incorrectly formed JSON")
+				}
+				section = name
+			}
+		}
+	}
+	raw, ok := m[section]
+	if !ok {
+		return nil, fmt.Errorf("section %q not found", section)
+	}
+
+	var items map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = map[string]json.RawMessage{}
+	}
+
+	for _, c := range l.Changes() {
+		_, present := items[c.Name]
+		switch {
+		case c.Op == OpCreate && present:
+			return nil, fmt.Errorf("cannot create %q: already present", c.Name)
+		case c.Op != OpCreate && !present:
+			return nil, fmt.Errorf("cannot %s %q: %w", c.Op, c.Name, ErrNameNotFound)
+		case c.Op == OpDelete:
+			delete(items, c.Name)
+		default:
+			items[c.Name] = c.Value
+		}
+	}
+
+	raw, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	m[section] = raw
+
+	return json.Marshal(m)
+}
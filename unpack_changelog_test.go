@@ -0,0 +1,65 @@
+package unpack
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangeLog(t *testing.T) {
+
+	b := []byte(`
+{
+	"Meta Data": { "symbol": "IBM" },
+	"readings": {
+		"2023-08-18": { "value": 1.5 },
+		"2023-08-21": { "value": 2.5 }
+	}
+}
+	`)
+
+	var l ChangeLog
+
+	r := &reading{Value: 3.5}
+	assert.Nil(t, l.Create("2023-08-22", r))
+	r.Value = 4.5 // Later changes are not recorded
+	assert.Nil(t, l.Update("2023-08-18", &reading{Value: 1.75}))
+	l.Delete("2023-08-21")
+
+	var buf bytes.Buffer
+	n, err := l.WriteTo(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+	assert.Equal(t, `{"op":"create","name":"2023-08-22","value":{"value":3.5}}
+{"op":"update","name":"2023-08-18","value":{"value":1.75}}
+{"op":"delete","name":"2023-08-21"}
+`, buf.String())
+
+	replayed, err := ReadChangeLog(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, l.Changes(), replayed.Changes())
+
+	out, err := replayed.Replay(b, WithSection("readings"))
+	assert.Nil(t, err)
+	assert.Equal(t, `{"Meta Data":{"symbol":"IBM"},"readings":{"2023-08-18":{"value":1.75},"2023-08-22":{"value":3.5}}}`, string(out))
+
+	u, err := Unpack(out, readingf{}, WithSection("readings"))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(u))
+
+	_, err = replayed.Replay(b)
+	assert.NotNil(t, err)
+
+	_, err = replayed.Replay(out, WithSection("readings"))
+	assert.Equal(t, `cannot create "2023-08-22": already present`, err.Error())
+
+	var missing ChangeLog
+	missing.Delete("2023-08-19")
+	_, err = missing.Replay(b, WithSection("readings"))
+	assert.ErrorIs(t, err, ErrNameNotFound)
+
+	_, err = ReadChangeLog(strings.NewReader(`{"op":"upsert","name":"x"}`))
+	assert.Equal(t, `line 1: unknown op "upsert"`, err.Error())
+}
@@ -0,0 +1,37 @@
+package unpack
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Decoder reads and decodes JSON values from an input stream
+type Decoder interface {
+	Decode(v interface{}) error
+}
+
+// Codec provides the JSON encoding and decoding used by Unpack,
+// allowing alternatives to encoding/json to be used
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+	NewDecoder(r io.Reader) Decoder
+}
+
+// StdCodec is the Codec provided by encoding/json, and is used by default
+type StdCodec struct{}
+
+// Marshal calls json.Marshal
+func (StdCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal calls json.Unmarshal
+func (StdCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// NewDecoder calls json.NewDecoder
+func (StdCodec) NewDecoder(r io.Reader) Decoder {
+	return json.NewDecoder(r)
+}
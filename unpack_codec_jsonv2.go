@@ -0,0 +1,50 @@
+//go:build goexperiment.jsonv2
+
+package unpack
+
+import (
+	"encoding/json/jsontext"
+	jsonv2 "encoding/json/v2"
+	"io"
+)
+
+// JSONv2Codec is a Codec that uses the experimental encoding/json/v2 and
+// encoding/json/jsontext packages, which avoid many of the allocations made by
+// encoding/json.  It is only available when building with GOEXPERIMENT=jsonv2.
+//
+// Note that encoding/json/v2 matches attribute names case sensitively by default;
+// jsonv2.MatchCaseInsensitiveNames(true) can be provided in Options if required.
+type JSONv2Codec struct {
+	Options []jsonv2.Options
+}
+
+// Marshal calls jsonv2.Marshal
+func (c JSONv2Codec) Marshal(v interface{}) ([]byte, error) {
+	return jsonv2.Marshal(v, c.Options...)
+}
+
+// Unmarshal calls jsonv2.Unmarshal
+func (c JSONv2Codec) Unmarshal(data []byte, v interface{}) error {
+	return jsonv2.Unmarshal(data, v, c.Options...)
+}
+
+// NewDecoder returns a Decoder that reads successive JSON values using jsontext
+func (c JSONv2Codec) NewDecoder(r io.Reader) Decoder {
+	return &jsonv2Decoder{
+		d:    jsontext.NewDecoder(r),
+		opts: c.Options,
+	}
+}
+
+type jsonv2Decoder struct {
+	d    *jsontext.Decoder
+	opts []jsonv2.Options
+}
+
+func (d *jsonv2Decoder) DisallowUnknownFields() {
+	d.opts = append(d.opts, jsonv2.RejectUnknownMembers(true))
+}
+
+func (d *jsonv2Decoder) Decode(v interface{}) error {
+	return jsonv2.UnmarshalDecode(d.d, v, d.opts...)
+}
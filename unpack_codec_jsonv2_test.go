@@ -0,0 +1,50 @@
+//go:build goexperiment.jsonv2
+
+package unpack
+
+import (
+	jsonv2 "encoding/json/v2"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnpackJSONv2Codec(t *testing.T) {
+
+	b := []byte(`
+{
+	"hosts": {
+		"gateway": { "IP": "10.0.0.1", "level": "high" },
+		"proxy": { "IP": "10.0.0.2", "level": "low" }
+	}
+}
+	`)
+
+	u, err := Unpack(b, hostf{}, WithCodec(JSONv2Codec{Options: []jsonv2.Options{jsonv2.MatchCaseInsensitiveNames(true)}}))
+	if err != nil {
+		t.Fatalf("Unexpected parse failure: %v", err)
+	}
+
+	assert.Equal(t, 2, len(u))
+	assert.Equal(t, "10.0.0.1", u[0].(*host).IP.String())
+	assert.Equal(t, level(1), u[1].(*host).Level)
+
+	d := JSONv2Codec{}.NewDecoder(strings.NewReader(`{"a": 1} {"a": 2}`))
+	for i := 1; i <= 2; i++ {
+		var m map[string]int
+		assert.Nil(t, d.Decode(&m))
+		assert.Equal(t, i, m["a"])
+	}
+}
+
+func TestUnpackJSONv2CodecStrictFields(t *testing.T) {
+
+	b := []byte(`{ "cities": { "Paris": { "population": 2100000, "mayor": "Anne Hidalgo" } } }`)
+
+	_, err := Unpack(b, cityf{}, WithCodec(JSONv2Codec{}))
+	assert.Nil(t, err)
+
+	_, err = Unpack(b, cityf{}, WithCodec(JSONv2Codec{}), WithStrictFields())
+	assert.NotNil(t, err)
+}
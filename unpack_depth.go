@@ -0,0 +1,38 @@
+package unpack
+
+// depth returns the deepest nesting of objects and arrays within the JSON,
+// without parsing it, so that hostile JSON can be rejected before decoding
+func depth(b []byte) int {
+	var (
+		d, max   int
+		inString bool
+		escaped  bool
+	)
+
+	for _, c := range b {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			d++
+			if d > max {
+				max = d
+			}
+		case '}', ']':
+			d--
+		}
+	}
+	return max
+}
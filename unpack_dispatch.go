@@ -0,0 +1,282 @@
+package unpack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrNoRoute is returned by Dispatch when the JSON object contains
+// none of the sections that have been registered
+var ErrNoRoute = errors.New("no registered section found")
+
+type route struct {
+	fact    UnpackableFactory
+	handler func(ctx context.Context, items []Unpackable) error
+	opts    []Option
+}
+
+// Dispatcher routes JSON objects containing different sections to the
+// handlers registered for them, centralising the routing logic needed when
+// integrating with multiple endpoints whose payloads are unpacked differently.
+// Each section is a named map of the form accepted by Unpack.
+// Handle must not be called concurrently with Dispatch.
+type Dispatcher struct {
+	routes map[string]route
+}
+
+// NewDispatcher returns a Dispatcher with no routes
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{
+		routes: map[string]route{},
+	}
+}
+
+// Handle registers the factory, handler and options for the section, replacing
+// any previous registration for that section.  The options apply to the
+// Unpackables of the section; those applying to the JSON object as a whole
+// (see UnpackSections) are not used.
+func (d *Dispatcher) Handle(section string, fact UnpackableFactory, handler func(ctx context.Context, items []Unpackable) error, opts ...Option) {
+	d.routes[section] = route{
+		fact:    fact,
+		handler: handler,
+		opts:    opts,
+	}
+}
+
+// Dispatch unpacks each registered section present in the JSON object, in
+// ascending order of section name, and calls the handler registered for it.
+// Sections that have not been registered are ignored; if no registered
+// section is present, ErrNoRoute is returned.  If a section's Unpackables are
+// returned together with an error (see WithContinueOnError), the handler is
+// still called, and the errors of all the sections are returned together
+// (as an Errors if there is more than one).
+func (d *Dispatcher) Dispatch(ctx context.Context, b []byte) error {
+
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+
+	var sections []string
+	for section := range m {
+		if _, ok := d.routes[section]; ok {
+			sections = append(sections, section)
+		}
+	}
+	if len(sections) == 0 {
+		return ErrNoRoute
+	}
+	sort.Strings(sections)
+
+	var errs error
+	for _, section := range sections {
+		r := d.routes[section]
+
+		opts := append(r.opts[:len(r.opts):len(r.opts)], withinSection(section, m))
+
+		items, err := UnpackContext(ctx, b, r.fact, opts...)
+		if err != nil && items == nil {
+			return join(errs, err)
+		}
+		errs = join(errs, err)
+
+		if err := r.handler(ctx, items); err != nil {
+			return join(errs, err)
+		}
+	}
+
+	return errs
+}
+
+// UnpackSections unpacks each of the sections of the JSON object, using the
+// factory given for it, for payloads that hold several collections (such as
+// weekly and daily series) in one JSON object.  The Unpackables are returned
+// by section.  An error is returned if any of the sections is not present.
+// Other sections, such as metadata, are ignored (see UnmarshalSection).
+// WithSkipSections, WithSectionHandler, WithUnknownSections,
+// WithCaptureExtras, WithErrorSections, WithMaxBytes and WithRecorder apply
+// once to the JSON object, and the others to the Unpackables of each section.
+// If a section's Unpackables are returned together with an error (see
+// WithContinueOnError), they are retained, and the errors of all the
+// sections are returned together (as an Errors if there is more than one).
+func UnpackSections(ctx context.Context, b []byte, facts map[string]UnpackableFactory, opts ...Option) (map[string][]Unpackable, error) {
+
+	m, err := decodeSections(b, newOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	return unpackSections(ctx, b, m, facts, opts)
+}
+
+// decodeSections returns the top level attributes of the JSON object, having
+// recorded it and checked its size and any provider error
+func decodeSections(b []byte, o *options) (map[string]json.RawMessage, error) {
+
+	if o.recorder != nil {
+		if err := o.recorder.Record(b); err != nil {
+			return nil, err
+		}
+	}
+
+	if o.maxBytes > 0 && len(b) > o.maxBytes {
+		return nil, &LimitError{Limit: "bytes", Max: o.maxBytes, Actual: len(b)}
+	}
+
+	var m map[string]json.RawMessage
+	if err := o.codec.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+
+	if err := providerError(m, o); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// unpackSections unpacks the sections of the decoded JSON object for
+// UnpackSections, applying the options relating to the other attributes once
+func unpackSections(ctx context.Context, b []byte, m map[string]json.RawMessage, facts map[string]UnpackableFactory, opts []Option) (map[string][]Unpackable, error) {
+
+	o := newOptions(opts)
+
+	sections := make([]string, 0, len(facts))
+	for section := range facts {
+		if _, ok := m[section]; !ok {
+			return nil, fmt.Errorf("section %q not found", section)
+		}
+		sections = append(sections, section)
+	}
+	sort.Strings(sections)
+
+	errs, err := handleOthers(m, func(name string) bool { _, ok := facts[name]; return ok }, o)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[string][]Unpackable, len(facts))
+	for _, section := range sections {
+		items, err := UnpackContext(ctx, b, facts[section], append(opts[:len(opts):len(opts)], withinSection(section, m))...)
+		if err != nil && items == nil {
+			return nil, err
+		}
+		ret[section] = items
+		errs = join(errs, err)
+	}
+
+	return ret, errs
+}
+
+// handleOthers applies WithSectionHandler, WithCaptureExtras and
+// WithUnknownSections to the top level attributes that are neither sections
+// nor excluded by the options, returning an *UnknownSectionsError as unknown
+// if they are to be reported with the Unpackables
+func handleOthers(m map[string]json.RawMessage, isSection func(name string) bool, o *options) (unknown error, err error) {
+
+	others := make(map[string]json.RawMessage, len(m))
+	for name, raw := range m {
+		if !isSection(name) && name != o.orderFrom && !o.isSkipped(name) {
+			others[name] = raw
+		}
+	}
+
+	if err := handleSections(others, o); err != nil {
+		return nil, err
+	}
+
+	if o.extras != nil {
+		o.extras(others)
+	}
+
+	if len(others) == 0 || o.unknownSections == UnknownSectionIgnore {
+		return nil, nil
+	}
+
+	e := &UnknownSectionsError{}
+	for name := range others {
+		e.Names = append(e.Names, name)
+	}
+	sort.Strings(e.Names)
+
+	if o.unknownSections == UnknownSectionError {
+		return nil, e
+	}
+	return e, nil
+}
+
+// withinSection replaces the options that apply to the JSON object as a
+// whole, which has already been decoded into m, so that the section can be
+// unpacked without decoding the JSON object again
+func withinSection(section string, m map[string]json.RawMessage) Option {
+	return func(o *options) {
+		withinPayload(o)
+		o.section = section
+		o.sectionPath = nil
+		o.decoded = map[string]json.RawMessage{section: m[section]}
+		if raw, ok := m[o.orderFrom]; ok && o.orderFrom != "" {
+			o.decoded[o.orderFrom] = raw
+		}
+	}
+}
+
+// UnpackAllSections unpacks every top level attribute of the JSON object as a
+// section of Unpackables created by the factory, returning them by section,
+// for payloads whose section names vary (such as by symbol or date).
+// Attributes that are not sections, such as metadata, are excluded using
+// WithSkipSections; that named by WithOrderFrom is excluded automatically.
+// The JSON object is decoded once, and the options apply as for UnpackSections.
+func UnpackAllSections[F UnpackableFactory](ctx context.Context, b []byte, fact F, opts ...Option) (map[string][]Unpackable, error) {
+
+	o := newOptions(opts)
+
+	m, err := decodeSections(b, o)
+	if err != nil {
+		return nil, err
+	}
+
+	facts := make(map[string]UnpackableFactory, len(m))
+	for section := range m {
+		if section != o.orderFrom && !o.isSkipped(section) {
+			facts[section] = fact
+		}
+	}
+
+	return unpackSections(ctx, b, m, facts, opts)
+}
+
+// handleSections passes the top level attributes matching the patterns of
+// WithSectionHandler to their handlers, other than that holding the
+// Unpackables if WithSection is used, and removes them from the JSON object
+func handleSections(m map[string]json.RawMessage, o *options) error {
+
+	if len(o.handlers) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(m))
+	for name := range m {
+		if name != o.section {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, h := range o.handlers {
+			if !h.pattern.MatchString(name) {
+				continue
+			}
+			if err := h.fn(name, m[name]); err != nil {
+				return err
+			}
+			delete(m, name)
+			break
+		}
+	}
+
+	return nil
+}
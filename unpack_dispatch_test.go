@@ -0,0 +1,330 @@
+package unpack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDispatcher(t *testing.T) {
+
+	var (
+		quotes    []string
+		countries []string
+	)
+
+	d := NewDispatcher()
+	d.Handle("quotes", quotef{}, func(ctx context.Context, items []Unpackable) error {
+		for _, item := range items {
+			quotes = append(quotes, item.(*quote).name)
+		}
+		return nil
+	})
+	d.Handle("countries", countryf{}, func(ctx context.Context, items []Unpackable) error {
+		for _, item := range items {
+			countries = append(countries, item.(*country).Capital)
+		}
+		return nil
+	}, WithOrdering(OrderingDescending))
+
+	ctx := context.Background()
+
+	assert.Nil(t, d.Dispatch(ctx, []byte(`{ "quotes": { "IBM": { "close": 140.5 } } }`)))
+	assert.Equal(t, []string{"IBM"}, quotes)
+	assert.Nil(t, countries)
+
+	assert.Nil(t, d.Dispatch(ctx, []byte(`{
+		"countries": { "FR": { "capital": "Paris" }, "UK": { "capital": "London" } },
+		"notes": "ignored",
+		"quotes": { "AAPL": { "close": 175.1 } }
+	}`)))
+	assert.Equal(t, []string{"IBM", "AAPL"}, quotes)
+	assert.Equal(t, []string{"London", "Paris"}, countries)
+
+	assert.ErrorIs(t, d.Dispatch(ctx, []byte(`{ "notes": {} }`)), ErrNoRoute)
+	assert.NotNil(t, d.Dispatch(ctx, []byte(`{ "quotes": { "IBM": { "close": "x" } } }`)))
+	assert.NotNil(t, d.Dispatch(ctx, []byte(`[]`)))
+
+	// Unpackables returned with errors are still handled
+	quotes = nil
+	d.Handle("quotes", quotef{}, func(ctx context.Context, items []Unpackable) error {
+		for _, item := range items {
+			quotes = append(quotes, item.(*quote).name)
+		}
+		return nil
+	}, WithContinueOnError())
+	err := d.Dispatch(ctx, []byte(`{ "quotes": { "IBM": { "close": "x" }, "AAPL": { "close": 175.1 } } }`))
+	var ue *UnpackError
+	assert.ErrorAs(t, err, &ue)
+	assert.Equal(t, "IBM", ue.Name)
+	assert.Equal(t, []string{"AAPL"}, quotes)
+
+	// Options relating to the JSON object are not applied for each route
+	dir := t.TempDir()
+	rec, err := NewRecorder(dir, nil)
+	assert.Nil(t, err)
+	noop := func(ctx context.Context, items []Unpackable) error { return nil }
+	d.Handle("quotes", quotef{}, noop, WithRecorder(rec))
+	d.Handle("countries", countryf{}, noop, WithRecorder(rec))
+	assert.Nil(t, d.Dispatch(ctx, []byte(`{
+		"countries": { "FR": { "capital": "Paris" } },
+		"quotes": { "AAPL": { "close": 175.1 } }
+	}`)))
+	files, err := os.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(files))
+}
+
+func TestUnpackSections(t *testing.T) {
+
+	b := []byte(`
+{
+	"Meta Data": { "symbol": "IBM" },
+	"Weekly Time Series": { "2023-08-18": { "close": 140.5 } },
+	"Daily Time Series": { "2023-08-18": { "close": 140.5 }, "2023-08-21": { "close": 141.25 } }
+}
+	`)
+
+	ctx := context.Background()
+
+	u, err := UnpackSections(ctx, b, map[string]UnpackableFactory{
+		"Weekly Time Series": quotef{},
+		"Daily Time Series":  quotef{},
+	}, WithOrdering(OrderingDescending))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(u))
+	assert.Equal(t, 1, len(u["Weekly Time Series"]))
+	assert.Equal(t, "2023-08-21", u["Daily Time Series"][0].(*quote).name)
+
+	_, err = UnpackSections(ctx, b, map[string]UnpackableFactory{"Monthly Time Series": quotef{}})
+	assert.Equal(t, `section "Monthly Time Series" not found`, err.Error())
+
+	// Options relating to the JSON object apply once
+	dir := t.TempDir()
+	rec, err := NewRecorder(dir, nil)
+	assert.Nil(t, err)
+	var extras map[string]json.RawMessage
+	_, err = UnpackSections(ctx, b, map[string]UnpackableFactory{
+		"Weekly Time Series": quotef{},
+		"Daily Time Series":  quotef{},
+	}, WithRecorder(rec), WithCaptureExtras(func(m map[string]json.RawMessage) { extras = m }), WithUnknownSections(UnknownSectionWarn))
+	var use *UnknownSectionsError
+	assert.ErrorAs(t, err, &use)
+	assert.Equal(t, []string{"Meta Data"}, use.Names)
+	assert.Equal(t, 1, len(extras))
+	files, err := os.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(files))
+
+	// Unpackables returned with errors are retained, and the errors joined
+	b = []byte(`
+{
+	"Weekly Time Series": { "2023-08-18": { "close": "x" }, "2023-08-25": { "close": 141.5 } },
+	"Daily Time Series": { "2023-08-18": { "close": 140.5 }, "2023-08-21": { "close": "y" } }
+}
+	`)
+	u, err = UnpackSections(ctx, b, map[string]UnpackableFactory{
+		"Weekly Time Series": quotef{},
+		"Daily Time Series":  quotef{},
+	}, WithContinueOnError())
+	assert.Equal(t, 1, len(u["Weekly Time Series"]))
+	assert.Equal(t, 1, len(u["Daily Time Series"]))
+	var errs Errors
+	assert.ErrorAs(t, err, &errs)
+	assert.Equal(t, 2, len(errs))
+}
+
+func TestUnpackAllSections(t *testing.T) {
+
+	b := []byte(`
+{
+	"Meta Data": { "symbol": "IBM" },
+	"IBM": { "2023-08-18": { "close": 140.5 } },
+	"AAPL": { "2023-08-18": { "close": 175.1 }, "2023-08-21": { "close": 175.8 } }
+}
+	`)
+
+	ctx := context.Background()
+
+	u, err := UnpackAllSections(ctx, b, quotef{}, WithSkipSections("Meta Data"))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(u))
+	assert.Equal(t, 1, len(u["IBM"]))
+	assert.Equal(t, 2, len(u["AAPL"]))
+
+	_, err = UnpackAllSections(ctx, b, quotef{})
+	assert.NotNil(t, err)
+
+	dir := t.TempDir()
+	rec, err := NewRecorder(dir, nil)
+	assert.Nil(t, err)
+	u, err = UnpackAllSections(ctx, b, quotef{}, WithSkipSections("Meta Data"), WithRecorder(rec))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(u))
+	files, err := os.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(files))
+
+	n, err := Count(ctx, []byte(`{ "Meta Data": { "symbol": "IBM" }, "IBM": { "2023-08-18": {} } }`), WithSkipSections("Meta Data"))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+
+	keys, err := Keys(ctx, []byte(`{ "Meta Data": { "symbol": "IBM" }, "IBM": { "2023-08-18": {} } }`), WithSkipSections("Meta Data"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2023-08-18"}, keys)
+}
+
+func TestUnpackSectionHandler(t *testing.T) {
+
+	b := []byte(`
+{
+	"Note": "Thank you for using our API",
+	"Warning": "Rate limit approaching",
+	"history": { "2023-08-18": { "close": 140.5 } }
+}
+	`)
+
+	_, err := Unpack(b, quotef{})
+	assert.NotNil(t, err)
+
+	var notices []string
+	notice := func(name string, raw json.RawMessage) error {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return err
+		}
+		notices = append(notices, name+": "+s)
+		return nil
+	}
+
+	u, err := Unpack(b, quotef{}, WithSectionHandler(regexp.MustCompile(`^(Note|Warning)$`), notice))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(u))
+	assert.Equal(t, []string{"Note: Thank you for using our API", "Warning: Rate limit approaching"}, notices)
+
+	// The section holding the Unpackables is never passed to a handler
+	notices = nil
+	u, err = Unpack(b, quotef{}, WithSection("history"), WithSectionHandler(regexp.MustCompile(`.`), notice))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(u))
+	assert.Equal(t, 2, len(notices))
+
+	fail := errors.New("provider warning")
+	_, err = Unpack(b, quotef{}, WithSectionHandler(regexp.MustCompile(`^Warning$`), func(string, json.RawMessage) error { return fail }))
+	assert.ErrorIs(t, err, fail)
+}
+
+func TestUnpackUnknownSections(t *testing.T) {
+
+	b := []byte(`
+{
+	"Note": "Thank you for using our API",
+	"Information": "Premium endpoint",
+	"history": { "2023-08-18": { "close": 140.5 } }
+}
+	`)
+
+	u, err := Unpack(b, quotef{}, WithSection("history"))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(u))
+
+	u, err = Unpack(b, quotef{}, WithSection("history"), WithUnknownSections(UnknownSectionWarn))
+	assert.Equal(t, 1, len(u))
+	var e *UnknownSectionsError
+	assert.ErrorAs(t, err, &e)
+	assert.Equal(t, []string{"Information", "Note"}, e.Names)
+
+	u, err = Unpack(b, quotef{}, WithSection("history"), WithUnknownSections(UnknownSectionError))
+	assert.Nil(t, u)
+	assert.ErrorAs(t, err, &e)
+
+	// Attributes that are skipped or handled are expected
+	u, err = Unpack(b, quotef{}, WithSection("history"), WithSkipSections("Information"),
+		WithSectionHandler(regexp.MustCompile(`^Note$`), func(string, json.RawMessage) error { return nil }),
+		WithUnknownSections(UnknownSectionError))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(u))
+}
+
+func TestUnpackCaptureExtras(t *testing.T) {
+
+	b := []byte(`
+{
+	"Meta Data": { "symbol": "IBM" },
+	"Dividends": { "2023-08-09": 1.66 },
+	"history": { "2023-08-18": { "close": 140.5 } }
+}
+	`)
+
+	var extras map[string]json.RawMessage
+	capture := WithCaptureExtras(func(m map[string]json.RawMessage) { extras = m })
+
+	u, err := Unpack(b, quotef{}, WithSection("history"), capture)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(u))
+	assert.Equal(t, 2, len(extras))
+	assert.Equal(t, `{ "2023-08-09": 1.66 }`, string(extras["Dividends"]))
+
+	_, err = Unpack(b, quotef{}, WithSection("history"), WithSkipSections("Meta Data"), capture)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(extras))
+	_, ok := extras["Meta Data"]
+	assert.False(t, ok)
+
+	_, err = Unpack(b, quotef{}, WithSection("history"), WithSkipSections("Meta Data", "Dividends"), capture)
+	assert.Nil(t, err)
+	assert.NotNil(t, extras)
+	assert.Equal(t, 0, len(extras))
+
+	// Each call receives its own extras, so the options can be shared concurrently
+	var (
+		mu    sync.Mutex
+		count int
+	)
+	opts := []Option{WithSection("history"), WithCaptureExtras(func(m map[string]json.RawMessage) {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(m) == 2 {
+			count++
+		}
+	})}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := Unpack(b, quotef{}, opts...)
+			assert.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 4, count)
+}
+
+func TestUnpackErrorSections(t *testing.T) {
+
+	b := []byte(`{ "Error Message": "Invalid API call. Please retry or visit the documentation." }`)
+
+	_, err := Unpack(b, quotef{}, WithSection("history"))
+	assert.Equal(t, `section "history" not found`, err.Error())
+
+	_, err = Unpack(b, quotef{}, WithSection("history"), WithErrorSections("Error Message", "Note"))
+	var pe *ProviderError
+	assert.ErrorAs(t, err, &pe)
+	assert.Equal(t, "Error Message", pe.Section)
+	assert.Equal(t, "Invalid API call. Please retry or visit the documentation.", pe.Message)
+
+	_, err = Unpack([]byte(`{ "Note": { "calls": 5 } }`), quotef{}, WithErrorSections("Error Message", "Note"))
+	assert.ErrorAs(t, err, &pe)
+	assert.Equal(t, `{ "calls": 5 }`, pe.Message)
+
+	u, err := Unpack([]byte(`{ "history": { "2023-08-18": { "close": 140.5 } } }`), quotef{}, WithErrorSections("Error Message", "Note"))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(u))
+}
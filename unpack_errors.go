@@ -0,0 +1,155 @@
+package unpack
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// LimitError is returned when the JSON exceeds a limit set by
+// WithMaxItems ("items"), WithMaxBytes ("bytes"), WithMaxDepth ("depth")
+// or WithMaxValueLength ("value length"), or when a GzipEnvelope
+// decompresses to more than its MaxBytes ("decompressed bytes")
+type LimitError struct {
+	Limit  string
+	Max    int
+	Actual int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("limit of %d %s exceeded: %d", e.Max, e.Limit, e.Actual)
+}
+
+// ErrDecodeDurationExceeded is returned when the Unpackables could not all be
+// populated within the duration specified by WithMaxDecodeDuration
+var ErrDecodeDurationExceeded = errors.New("decode duration exceeded")
+
+// ErrNotModified is returned by UnpackIfNewer when the JSON is not newer than
+// the caller's last refresh
+var ErrNotModified = errors.New("not modified")
+
+// ErrPathNotFound is matched by the *PathError returned when a JSON Pointer
+// does not refer to a value within the JSON
+var ErrPathNotFound = errors.New("path not found")
+
+// PathError is returned by UnmarshalAtPointer when the JSON Pointer does not
+// refer to a value.  Prefix is the longest prefix of the pointer that does
+// (the empty string referring to the whole JSON).
+type PathError struct {
+	Pointer string
+	Prefix  string
+}
+
+func (e *PathError) Error() string {
+	return fmt.Sprintf("%v: %q (nearest %q)", ErrPathNotFound, e.Pointer, e.Prefix)
+}
+
+func (e *PathError) Unwrap() error {
+	return ErrPathNotFound
+}
+
+// UnknownSectionsError lists, in ascending order, the unexpected top level
+// attributes of the JSON object reported due to WithUnknownSections
+type UnknownSectionsError struct {
+	Names []string
+}
+
+func (e *UnknownSectionsError) Error() string {
+	return fmt.Sprintf("unknown sections %q", e.Names)
+}
+
+// ProviderError is returned when the JSON object includes one of the top
+// level attributes named by WithErrorSections, indicating that the provider
+// returned an error message rather than data.  Message is the attribute's
+// value, or its raw JSON if the value is not a string.
+type ProviderError struct {
+	Section string
+	Message string
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("provider error %q: %s", e.Section, e.Message)
+}
+
+// providerError returns a ProviderError for the first of the attributes
+// named by WithErrorSections that is present in the JSON object, if any
+func providerError(m map[string]json.RawMessage, o *options) error {
+	for _, section := range o.errorSections {
+		raw, ok := m[section]
+		if !ok {
+			continue
+		}
+
+		var msg string
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			msg = string(raw)
+		}
+		return &ProviderError{Section: section, Message: msg}
+	}
+	return nil
+}
+
+// Errors is returned, together with the Unpackables that were successfully
+// populated, when WithContinueOnError is used and one or more Unpackables
+// could not be populated.  There is an error for each failed Unpackable.
+type Errors []error
+
+func (e Errors) Error() string {
+	s := make([]string, len(e))
+	for i, err := range e {
+		s[i] = err.Error()
+	}
+	return strings.Join(s, "\n")
+}
+
+// Unwrap returns the individual errors, for use with errors.Is and errors.As
+func (e Errors) Unwrap() []error {
+	return e
+}
+
+// UnpackError describes the failure to populate an Unpackable, identifying
+// the Unpackable by name and, where known, the path to the attribute within
+// its JSON object that could not be decoded (for example "population.2023")
+type UnpackError struct {
+	Name string
+	Path string
+	Err  error
+}
+
+func (e *UnpackError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("%q: %v", e.Name, e.Err)
+	}
+	return fmt.Sprintf("%q: attribute %q: %v", e.Name, e.Path, e.Err)
+}
+
+func (e *UnpackError) Unwrap() error {
+	return e.Err
+}
+
+// newUnpackError returns an UnpackError for the named Unpackable,
+// determining the path of the failing attribute from the error
+func newUnpackError(name string, err error) *UnpackError {
+	e := &UnpackError{Name: name, Err: err}
+
+	var (
+		ue *UnpackError
+		te *json.UnmarshalTypeError
+	)
+
+	switch {
+	case errors.As(err, &ue):
+		e.Path, e.Err = ue.Path, ue.Err
+	case errors.As(err, &te):
+		e.Path = te.Field
+	default:
+		// encoding/json does not provide a type for unknown attribute errors
+		const prefix = `json: unknown field "`
+		if msg := err.Error(); strings.HasPrefix(msg, prefix) {
+			e.Path = strings.TrimSuffix(strings.TrimPrefix(msg, prefix), `"`)
+		}
+	}
+
+	return e
+}
@@ -0,0 +1,151 @@
+package unpack
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+)
+
+// ExplainPlan returns a description of how the Unpackables of type T would be
+// populated given the options: the attribute each field is populated from and
+// how it is converted, whether the JSON objects are decoded directly or first
+// rewritten, and how the population is scheduled.  It is intended to help
+// diagnose why a payload is slow to unpack or loses data.
+func ExplainPlan[T Unpackable](opts ...Option) string {
+
+	o := newOptions(opts)
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	p := newPlan(t, o.tagName)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "type: %v\n", t)
+
+	if p.err != nil {
+		fmt.Fprintf(&sb, "error: %v\n", p.err)
+		return sb.String()
+	}
+
+	var reasons []string
+	for _, f := range p.times {
+		if f.layout != "" || len(o.timeLayouts) > 0 {
+			reasons = append(reasons, "time layouts")
+			break
+		}
+	}
+	if len(p.ifaces) > 0 {
+		reasons = append(reasons, "interface fields")
+	}
+	if p.remain != nil {
+		reasons = append(reasons, "remain field")
+	}
+	if o.caseSensitive {
+		reasons = append(reasons, "case sensitive fields")
+	}
+	if len(o.decodeHooks) > 0 {
+		reasons = append(reasons, "decode hooks")
+	}
+	if o.weakTyping {
+		reasons = append(reasons, "weak typing")
+	}
+	if o.tagName != "" {
+		reasons = append(reasons, fmt.Sprintf("tag %q", o.tagName))
+	}
+
+	if len(reasons) == 0 {
+		fmt.Fprintf(&sb, "decode: directly by %T\n", o.codec)
+	} else {
+		fmt.Fprintf(&sb, "decode: attributes rewritten, then by %T (%s)\n", o.codec, strings.Join(reasons, ", "))
+	}
+
+	switch {
+	case o.parallelism > 1:
+		fmt.Fprintf(&sb, "population: parallel, %d goroutines\n", o.parallelism)
+	case o.parallelism <= 1 && o.auto:
+		sb.WriteString("population: automatic, by payload size\n")
+	default:
+		sb.WriteString("population: sequential\n")
+	}
+
+	st := t
+	if st.Kind() == reflect.Pointer {
+		st = st.Elem()
+	}
+	if st.Kind() != reflect.Struct {
+		return sb.String()
+	}
+
+	sb.WriteString("fields:\n")
+	w := tabwriter.NewWriter(&sb, 0, 4, 2, ' ', 0)
+
+	var explain func(st reflect.Type, prefix string, index []int)
+	explain = func(st reflect.Type, prefix string, index []int) {
+		for i := 0; i < st.NumField(); i++ {
+			sf := st.Field(i)
+			sf.Index = append(append([]int{}, index...), i)
+
+			source, conversion := explainField(sf, p, o)
+			fmt.Fprintf(w, "  %s%s\t%s\t%s\n", prefix, sf.Name, source, conversion)
+
+			if ft := promoted(sf); ft != nil {
+				explain(ft, prefix+sf.Name+".", sf.Index)
+			}
+		}
+	}
+	explain(st, "", nil)
+	w.Flush()
+
+	return sb.String()
+}
+
+// explainField describes the attribute the field is populated from, and how
+func explainField(sf reflect.StructField, p *plan, o *options) (string, string) {
+
+	switch {
+	case promoted(sf) != nil:
+		return "-", "embedded, fields promoted"
+	case !sf.IsExported():
+		return "-", "not populated (unexported)"
+	case reflect.DeepEqual(sf.Index, p.remain):
+		return "<- unmatched attributes", sf.Type.String()
+	}
+
+	// As newPlan
+	key := tagName(sf, "json")
+	if key != "-" && o.tagName != "" {
+		key = tagName(sf, o.tagName)
+	}
+	if key == "-" {
+		return "-", "not populated (ignored by tag)"
+	}
+	if !reflect.DeepEqual(p.index[key], sf.Index) {
+		return "-", "not populated (hidden by a shallower field)"
+	}
+
+	for _, f := range p.times {
+		if f.key != key {
+			continue
+		}
+		switch {
+		case f.layout != "":
+			return fmt.Sprintf("<- %q", key), fmt.Sprintf("time, layout %q", f.layout)
+		case len(o.timeLayouts) > 0:
+			return fmt.Sprintf("<- %q", key), fmt.Sprintf("time, layouts %q", o.timeLayouts)
+		default:
+			return fmt.Sprintf("<- %q", key), "time, RFC 3339 only"
+		}
+	}
+
+	conv := sf.Type.String()
+	switch {
+	case sf.Type.Kind() == reflect.Interface && sf.Type.NumMethod() > 0:
+		conv += fmt.Sprintf(", registered type selected by %q", o.discriminator)
+	case sf.Type.Kind() == reflect.Interface:
+		conv += ", generic (maps, slices, float64)"
+	case reflect.PointerTo(sf.Type).Implements(reflect.TypeOf((*interface{ UnmarshalJSON([]byte) error })(nil)).Elem()):
+		conv += ", UnmarshalJSON"
+	case reflect.PointerTo(sf.Type).Implements(reflect.TypeOf((*interface{ UnmarshalText([]byte) error })(nil)).Elem()):
+		conv += ", UnmarshalText"
+	}
+	return fmt.Sprintf("<- %q", key), conv
+}
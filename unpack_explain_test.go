@@ -0,0 +1,39 @@
+package unpack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplainPlan(t *testing.T) {
+
+	s := ExplainPlan[*quote](WithParallelism(4))
+	assert.Contains(t, s, "type: *unpack.quote\n")
+	assert.Contains(t, s, "decode: attributes rewritten, then by unpack.StdCodec (time layouts)\n")
+	assert.Contains(t, s, "population: parallel, 4 goroutines\n")
+	assert.Contains(t, s, "  name      -              not populated (unexported)\n")
+	assert.Contains(t, s, `  Date      <- "date"      time, layout "2006-01-02"`)
+	assert.Contains(t, s, `  Settled   <- "settled"   time, RFC 3339 only`)
+	assert.Contains(t, s, `  Close     <- "close"     float64`)
+
+	s = ExplainPlan[*quote](WithTimeLayouts("02/01/2006"))
+	assert.Contains(t, s, `time, layouts ["02/01/2006"]`)
+
+	s = ExplainPlan[*capital]()
+	assert.Contains(t, s, "(time layouts, remain field)")
+	assert.Contains(t, s, "<- unmatched attributes")
+
+	s = ExplainPlan[*station](WithTagName("mapstructure"), WithAutoStrategy())
+	assert.Contains(t, s, "population: automatic, by payload size\n")
+	assert.Contains(t, s, `<- "station_code"`)
+	assert.Contains(t, s, "  Zone     -                  not populated (ignored by tag)\n")
+
+	s = ExplainPlan[*host]()
+	assert.Contains(t, s, "decode: directly by unpack.StdCodec\n")
+	assert.Contains(t, s, "population: sequential\n")
+	assert.Contains(t, s, "net.IP, UnmarshalText")
+
+	s = ExplainPlan[*badRemain]()
+	assert.Contains(t, s, "error: remain field Other must be a map with string keys\n")
+}
@@ -0,0 +1,289 @@
+package unpack
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+var (
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// dtoField is an attribute of the JSON encoding of a struct
+type dtoField struct {
+	name     string
+	t        reflect.Type
+	optional bool
+}
+
+// dtoStruct describes the JSON encoding of a struct
+type dtoStruct struct {
+	name   string
+	fields []dtoField
+}
+
+// dtoStructs returns the descriptions of T and the structs it refers to,
+// as they are encoded by encoding/json, in the order they are first referred to
+func dtoStructs(t reflect.Type) []dtoStruct {
+
+	var (
+		ret  []dtoStruct
+		seen = map[reflect.Type]bool{}
+		add  func(t reflect.Type)
+	)
+
+	add = func(t reflect.Type) {
+		t = dtoElem(t)
+		if t.Kind() != reflect.Struct || seen[t] || dtoScalar(t) {
+			return
+		}
+		seen[t] = true
+
+		s := dtoStruct{name: dtoName(t)}
+		var refs []reflect.Type
+
+		var fields func(t reflect.Type)
+		fields = func(t reflect.Type) {
+			for i := 0; i < t.NumField(); i++ {
+				sf := t.Field(i)
+				if ft := promoted(sf); ft != nil {
+					fields(ft)
+					continue
+				}
+				if !sf.IsExported() {
+					continue
+				}
+				tag := sf.Tag.Get("json")
+				name, opts, _ := strings.Cut(tag, ",")
+				if name == "-" && opts == "" {
+					continue
+				}
+				if name == "" {
+					name = sf.Name
+				}
+				s.fields = append(s.fields, dtoField{
+					name:     name,
+					t:        sf.Type,
+					optional: strings.Contains(","+opts+",", ",omitempty,") || sf.Type.Kind() == reflect.Pointer,
+				})
+				refs = append(refs, sf.Type)
+			}
+		}
+		fields(t)
+
+		ret = append(ret, s)
+		for _, r := range refs {
+			add(r)
+		}
+	}
+
+	add(t)
+	return ret
+}
+
+// dtoElem returns the type of the elements of pointers, slices,
+// arrays and maps, or the type itself
+func dtoElem(t reflect.Type) reflect.Type {
+	for {
+		switch {
+		case dtoScalar(t):
+			return t
+		case t.Kind() == reflect.Pointer, t.Kind() == reflect.Slice, t.Kind() == reflect.Array, t.Kind() == reflect.Map:
+			t = t.Elem()
+		default:
+			return t
+		}
+	}
+}
+
+// dtoScalar reports whether the type is encoded as a JSON string or
+// as arbitrary JSON, rather than according to its kind
+func dtoScalar(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		return false
+	}
+	return t == rawMessageType || t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) ||
+		(t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8)
+}
+
+// dtoName returns the exported form of the name of the type
+func dtoName(t reflect.Type) string {
+	r := []rune(t.Name())
+	if len(r) == 0 {
+		return "Anonymous"
+	}
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+// ExportTypeScript returns TypeScript interfaces describing the JSON encoding
+// of T, and of the structs it refers to, together with a type describing a
+// named collection of T, so that consumers of JSON produced from the Go
+// types can stay in step with them.
+func ExportTypeScript[T Unpackable]() string {
+
+	var sb strings.Builder
+	structs := dtoStructs(reflect.TypeOf((*T)(nil)).Elem())
+
+	for _, s := range structs {
+		fmt.Fprintf(&sb, "export interface %s {\n", s.name)
+		for _, f := range s.fields {
+			name := f.name
+			if !isIdentifier(name) {
+				name = fmt.Sprintf("%q", name)
+			}
+			opt := ""
+			if f.optional {
+				opt = "?"
+			}
+			fmt.Fprintf(&sb, "  %s%s: %s;\n", name, opt, typeScriptType(f.t))
+		}
+		sb.WriteString("}\n\n")
+	}
+
+	if len(structs) > 0 {
+		fmt.Fprintf(&sb, "export type %sCollection = Record<string, %s>;\n", structs[0].name, structs[0].name)
+	}
+	return sb.String()
+}
+
+// typeScriptType returns the TypeScript type of the JSON encoding of t
+func typeScriptType(t reflect.Type) string {
+	switch {
+	case t == rawMessageType:
+		return "unknown"
+	case dtoScalar(t):
+		return "string"
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return typeScriptType(t.Elem()) + " | null"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.String:
+		return "string"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		elem := typeScriptType(t.Elem())
+		if strings.Contains(elem, " ") {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]"
+	case reflect.Map:
+		return "Record<string, " + typeScriptType(t.Elem()) + ">"
+	case reflect.Struct:
+		return dtoName(t)
+	}
+	return "unknown"
+}
+
+// ExportPython returns Python dataclasses describing the JSON encoding of T,
+// and of the structs it refers to, together with a type alias describing a
+// named collection of T.  Attributes whose names are not Python identifiers
+// are given valid names, with the JSON name recorded in a comment.
+func ExportPython[T Unpackable]() string {
+
+	var sb strings.Builder
+	structs := dtoStructs(reflect.TypeOf((*T)(nil)).Elem())
+
+	sb.WriteString("from __future__ import annotations\n\n")
+	sb.WriteString("from dataclasses import dataclass\n")
+	sb.WriteString("from typing import Any, Optional\n")
+
+	for _, s := range structs {
+		fmt.Fprintf(&sb, "\n\n@dataclass\nclass %s:\n", s.name)
+		if len(s.fields) == 0 {
+			sb.WriteString("    pass\n")
+		}
+
+		// Fields with defaults must follow those without
+		for _, optional := range []bool{false, true} {
+			for _, f := range s.fields {
+				if f.optional != optional {
+					continue
+				}
+				name, comment := pythonName(f.name), ""
+				if name != f.name {
+					comment = fmt.Sprintf("  # JSON: %q", f.name)
+				}
+				typ, def := pythonType(f.t), ""
+				if optional {
+					if f.t.Kind() != reflect.Pointer {
+						typ = "Optional[" + typ + "]"
+					}
+					def = " = None"
+				}
+				fmt.Fprintf(&sb, "    %s: %s%s%s\n", name, typ, def, comment)
+			}
+		}
+	}
+
+	if len(structs) > 0 {
+		fmt.Fprintf(&sb, "\n\n%sCollection = dict[str, %s]\n", structs[0].name, structs[0].name)
+	}
+	return sb.String()
+}
+
+// pythonType returns the Python type of the JSON encoding of t
+func pythonType(t reflect.Type) string {
+	switch {
+	case t == rawMessageType:
+		return "Any"
+	case dtoScalar(t):
+		return "str"
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return "Optional[" + pythonType(t.Elem()) + "]"
+	case reflect.Bool:
+		return "bool"
+	case reflect.String:
+		return "str"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "int"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	case reflect.Slice, reflect.Array:
+		return "list[" + pythonType(t.Elem()) + "]"
+	case reflect.Map:
+		return "dict[str, " + pythonType(t.Elem()) + "]"
+	case reflect.Struct:
+		return dtoName(t)
+	}
+	return "Any"
+}
+
+// isIdentifier reports whether s is a valid identifier in TypeScript and Python
+func isIdentifier(s string) bool {
+	for i, r := range s {
+		if !(r == '_' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r))) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// pythonName returns s, with any characters not valid in a Python identifier replaced
+func pythonName(s string) string {
+	if isIdentifier(s) {
+		return s
+	}
+	r := []rune(s)
+	for i, c := range r {
+		if !(c == '_' || unicode.IsLetter(c) || (i > 0 && unicode.IsDigit(c))) {
+			r[i] = '_'
+		}
+	}
+	return string(r)
+}
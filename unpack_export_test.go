@@ -0,0 +1,90 @@
+package unpack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type dtoLocation struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+type dtoStation struct {
+	Audited
+	name     string
+	Code     string                  `json:"code"`
+	Location *dtoLocation            `json:"location"`
+	Lines    []string                `json:"lines,omitempty"`
+	Counts   map[string][]int        `json:"counts"`
+	Zone     *int                    `json:"fare-zone"`
+	Stops    []*dtoLocation          `json:"stops"`
+	Extra    map[string]interface{}  `json:"-"`
+	Raw      map[string]level        `json:"raw"`
+	Nearby   map[string]*dtoLocation `json:"nearby"`
+}
+
+func (s *dtoStation) SetName(name string) {
+	s.name = name
+}
+
+func TestExportTypeScript(t *testing.T) {
+
+	expected := `export interface DtoStation {
+  created_at: string;
+  updated_at?: string | null;
+  code: string;
+  location?: DtoLocation | null;
+  lines?: string[];
+  counts: Record<string, number[]>;
+  "fare-zone"?: number | null;
+  stops: (DtoLocation | null)[];
+  raw: Record<string, number>;
+  nearby: Record<string, DtoLocation | null>;
+}
+
+export interface DtoLocation {
+  lat: number;
+  lon: number;
+}
+
+export type DtoStationCollection = Record<string, DtoStation>;
+`
+
+	assert.Equal(t, expected, ExportTypeScript[*dtoStation]())
+}
+
+func TestExportPython(t *testing.T) {
+
+	expected := `from __future__ import annotations
+
+from dataclasses import dataclass
+from typing import Any, Optional
+
+
+@dataclass
+class DtoStation:
+    created_at: str
+    code: str
+    counts: dict[str, list[int]]
+    stops: list[Optional[DtoLocation]]
+    raw: dict[str, int]
+    nearby: dict[str, Optional[DtoLocation]]
+    updated_at: Optional[str] = None
+    location: Optional[DtoLocation] = None
+    lines: Optional[list[str]] = None
+    fare_zone: Optional[int] = None  # JSON: "fare-zone"
+
+
+@dataclass
+class DtoLocation:
+    lat: float
+    lon: float
+
+
+DtoStationCollection = dict[str, DtoStation]
+`
+
+	assert.Equal(t, expected, ExportPython[*dtoStation]())
+}
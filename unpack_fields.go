@@ -0,0 +1,588 @@
+package unpack
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// planCache holds the *plan for each reflect.Type and tag name that has been
+// unpacked, so that the struct tags are only inspected once per type
+var planCache sync.Map
+
+// planKey identifies a plan within the planCache
+type planKey struct {
+	t   reflect.Type
+	tag string
+}
+
+// field describes an attribute of the receiving struct that requires
+// handling beyond that provided by encoding/json
+type field struct {
+	index  []int
+	key    string
+	layout string
+	ptr    bool
+}
+
+// fieldDefault is the JSON value used to populate a field before the JSON
+// object is decoded, from its default tag
+type fieldDefault struct {
+	index []int
+	raw   json.RawMessage
+}
+
+// plan describes how the struct that an Unpackable points to is populated
+type plan struct {
+	times   []field
+	ifaces  []field           // fields of non-empty interface types
+	defs    []fieldDefault    // fields with a default tag
+	keys    map[string]string // lower case attribute names of the fields, to the attribute names
+	exact   map[string]string // attribute names of the fields, to their encoding/json names
+	index   map[string][]int  // attribute names of the fields, to their indexes
+	remain  []int             // index of the field receiving unmatched attributes
+	deleted []int             // index of the field flagging a tombstone
+	err     error
+}
+
+// cachedPlan returns the plan for the type and tag name, from the cache if available
+func cachedPlan(t reflect.Type, tag string) *plan {
+	k := planKey{t: t, tag: tag}
+	if p, ok := planCache.Load(k); ok {
+		return p.(*plan)
+	}
+	p, _ := planCache.LoadOrStore(k, newPlan(t, tag))
+	return p.(*plan)
+}
+
+// tagName returns the name of the field given by the tag, or "-" if the
+// field is to be ignored
+func tagName(sf reflect.StructField, tag string) string {
+	if t, ok := sf.Tag.Lookup(tag); ok {
+		if name, _, _ := strings.Cut(t, ","); name != "" {
+			return name
+		}
+	}
+	return sf.Name
+}
+
+// newPlan inspects the fields of the struct that the Unpackable points to,
+// taking the attribute names of the fields from the tag, or from the json
+// tag if the tag is "".  As with encoding/json, the fields of embedded
+// structs are promoted, with shallower fields taking precedence.
+func newPlan(t reflect.Type, tag string) *plan {
+	p := &plan{
+		keys:  map[string]string{},
+		exact: map[string]string{},
+		index: map[string][]int{},
+	}
+
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return p
+	}
+
+	type embedded struct {
+		t     reflect.Type
+		index []int
+	}
+
+	for level := []embedded{{t: t}}; len(level) > 0; {
+		var next []embedded
+
+		for _, e := range level {
+			for i := 0; i < e.t.NumField(); i++ {
+				sf := e.t.Field(i)
+				sf.Index = append(append([]int{}, e.index...), i)
+
+				if ft := promoted(sf); ft != nil {
+					next = append(next, embedded{t: ft, index: sf.Index})
+					continue
+				}
+				if !sf.IsExported() {
+					continue
+				}
+
+				_, opts, _ := strings.Cut(sf.Tag.Get("unpack"), ",")
+				if opts == "deleted" {
+					if sf.Type.Kind() != reflect.Bool {
+						p.err = fmt.Errorf("deleted field %s must be a bool", sf.Name)
+					}
+					if p.deleted == nil {
+						p.deleted = sf.Index
+					}
+				}
+				if opts == "remain" {
+					if mt := indirect(sf.Type); mt.Kind() != reflect.Map || mt.Key().Kind() != reflect.String {
+						p.err = fmt.Errorf("remain field %s must be a map with string keys", sf.Name)
+					}
+					if p.remain == nil {
+						p.remain = sf.Index
+					}
+					continue
+				}
+
+				name := tagName(sf, "json")
+				if name == "-" {
+					continue
+				}
+
+				key := name
+				if tag != "" {
+					if key = tagName(sf, tag); key == "-" {
+						continue
+					}
+				}
+				if _, ok := p.exact[key]; ok {
+					continue
+				}
+				p.keys[strings.ToLower(key)] = key
+				p.exact[key] = name
+				p.index[key] = sf.Index
+
+				if def, ok := sf.Tag.Lookup("default"); ok {
+					raw, err := defaultValue(def, sf)
+					if err != nil {
+						p.err = fmt.Errorf("default of field %s: %w", sf.Name, err)
+					}
+					p.defs = append(p.defs, fieldDefault{index: sf.Index, raw: raw})
+				}
+
+				switch sf.Type {
+				case timeType:
+					p.times = append(p.times, field{index: sf.Index, key: key, layout: sf.Tag.Get("layout")})
+				case reflect.PointerTo(timeType):
+					p.times = append(p.times, field{index: sf.Index, key: key, layout: sf.Tag.Get("layout"), ptr: true})
+				default:
+					if sf.Type.Kind() == reflect.Interface && sf.Type.NumMethod() > 0 {
+						p.ifaces = append(p.ifaces, field{index: sf.Index, key: key})
+					}
+				}
+			}
+		}
+
+		level = next
+	}
+	return p
+}
+
+// promoted returns the struct type of an embedded field whose fields are
+// promoted, as by encoding/json, or nil
+func promoted(sf reflect.StructField) reflect.Type {
+	if !sf.Anonymous {
+		return nil
+	}
+	ft := sf.Type
+	if ft.Kind() == reflect.Pointer {
+		ft = ft.Elem()
+	}
+	if _, ok := sf.Tag.Lookup("json"); ok || ft.Kind() != reflect.Struct {
+		return nil
+	}
+	// encoding/json cannot allocate unexported embedded pointers
+	if !sf.IsExported() && sf.Type.Kind() == reflect.Pointer {
+		return nil
+	}
+	return ft
+}
+
+// indirect returns the type that t points to, through any number of pointers
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// fieldByIndex returns the nested field of the struct, allocating any
+// nil pointers to embedded structs on the way
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// DecodeMap populates v, which must be a non-nil pointer, from the map as
+// though it were the JSON object of an Unpackable, so that the options
+// affecting how Unpackables are populated (such as WithTimeLayouts,
+// WithStrictFields, WithTagName, WithWeakTyping and WithDecodeHook) can be
+// used for data that is not a collection of named JSON objects.
+func DecodeMap(m map[string]interface{}, v interface{}, opts ...Option) error {
+
+	if rv := reflect.ValueOf(v); rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("cannot decode into %T: not a non-nil pointer", v)
+	}
+
+	o := newOptions(opts)
+
+	b, err := o.codec.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if err := setDefaults(v, o); err != nil {
+		return err
+	}
+	if err := decodeItem(b, v, o); err != nil {
+		return err
+	}
+
+	if o.interner != nil {
+		o.interner.walk(reflect.ValueOf(v))
+	}
+	return nil
+}
+
+// decodeItem populates the Unpackable, or other pointer, from the JSON object
+func decodeItem(b []byte, r interface{}, o *options) error {
+
+	p := cachedPlan(reflect.TypeOf(r), o.tagName)
+	if p.err != nil {
+		return p.err
+	}
+
+	var fields []field
+	for _, f := range p.times {
+		if f.layout != "" || len(o.timeLayouts) > 0 {
+			fields = append(fields, f)
+		}
+	}
+
+	if len(fields) == 0 && len(p.ifaces) == 0 && p.remain == nil && !o.caseSensitive && o.tagName == "" && !o.weakTyping && len(o.decodeHooks) == 0 {
+		return unmarshal(b, r, o)
+	}
+
+	// Remove the attributes that are handled here, so that
+	// encoding/json doesn't attempt (and fail) to parse them
+	var m map[string]json.RawMessage
+	if err := o.codec.Unmarshal(b, &m); err != nil {
+		return err
+	}
+
+	raws := make([]json.RawMessage, len(fields))
+	for i, f := range fields {
+		raws[i] = take(m, f.key, o)
+	}
+
+	ifaces := make([]json.RawMessage, len(p.ifaces))
+	for i, f := range p.ifaces {
+		ifaces[i] = take(m, f.key, o)
+	}
+
+	// Attributes matching a field only if case is ignored are unmatched
+	var unmatched map[string]json.RawMessage
+	if o.caseSensitive {
+		for k, raw := range m {
+			if _, ok := p.exact[k]; !ok && p.keys[strings.ToLower(k)] != "" {
+				if unmatched == nil {
+					unmatched = map[string]json.RawMessage{}
+				}
+				unmatched[k] = raw
+				delete(m, k)
+			}
+		}
+	}
+
+	if o.strictFields && p.remain == nil && len(unmatched) > 0 {
+		return unknownField(unmatched)
+	}
+
+	var remain map[string]json.RawMessage
+	if p.remain != nil {
+		for k, raw := range unmatched {
+			if remain == nil {
+				remain = map[string]json.RawMessage{}
+			}
+			remain[k] = raw
+		}
+		for k, raw := range m {
+			if p.keys[strings.ToLower(k)] == "" {
+				if remain == nil {
+					remain = map[string]json.RawMessage{}
+				}
+				remain[k] = raw
+				delete(m, k)
+			}
+		}
+	}
+
+	// Values produced by decode hooks that are set directly on the fields
+	var hooked []hookedValue
+
+	if len(o.decodeHooks) > 0 || o.weakTyping {
+		t := reflect.TypeOf(r).Elem()
+		for k, raw := range m {
+			index, ok := p.index[fieldKey(p, k, o)]
+			if !ok {
+				continue
+			}
+			ft := t.FieldByIndex(index).Type
+
+			if len(o.decodeHooks) > 0 {
+				var (
+					hv  reflect.Value
+					err error
+				)
+				if raw, hv, err = applyHooks(raw, ft, o); err != nil {
+					return &UnpackError{Path: k, Err: err}
+				}
+				if hv.IsValid() {
+					hooked = append(hooked, hookedValue{index: index, v: hv})
+					delete(m, k)
+					continue
+				}
+			}
+
+			if o.weakTyping {
+				raw = weaken(raw, ft)
+			}
+			m[k] = raw
+		}
+	}
+
+	// Rename the attributes to those encoding/json expects for the fields
+	if o.tagName != "" {
+		renamed := make(map[string]json.RawMessage, len(m))
+		unknown := map[string]json.RawMessage{}
+		for k, raw := range m {
+			name, ok := p.exact[k]
+			if !ok {
+				name, ok = p.exact[p.keys[strings.ToLower(k)]]
+			}
+			if !ok {
+				unknown[k] = raw
+				continue
+			}
+			renamed[name] = raw
+		}
+		if o.strictFields && len(unknown) > 0 {
+			return unknownField(unknown)
+		}
+		m = renamed
+	}
+
+	b, err := o.codec.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if err := unmarshal(b, r, o); err != nil {
+		return err
+	}
+
+	v := reflect.ValueOf(r).Elem()
+	for _, h := range hooked {
+		fieldByIndex(v, h.index).Set(h.v)
+	}
+
+	for i, f := range fields {
+		if len(raws[i]) == 0 || string(raws[i]) == "null" {
+			continue
+		}
+
+		var s string
+		if err := o.codec.Unmarshal(raws[i], &s); err != nil {
+			return &UnpackError{Path: f.key, Err: err}
+		}
+
+		layouts := o.timeLayouts
+		if f.layout != "" {
+			layouts = []string{f.layout}
+		}
+
+		t, err := parseTime(s, layouts)
+		if err != nil {
+			return &UnpackError{Path: f.key, Err: err}
+		}
+
+		fv := fieldByIndex(v, f.index)
+		if f.ptr {
+			fv.Set(reflect.ValueOf(&t))
+		} else {
+			fv.Set(reflect.ValueOf(t))
+		}
+	}
+
+	for i, f := range p.ifaces {
+		if len(ifaces[i]) == 0 || string(ifaces[i]) == "null" {
+			continue
+		}
+
+		fv := fieldByIndex(v, f.index)
+		iv, err := decodeInterface(ifaces[i], fv.Type(), o)
+		if err != nil {
+			return &UnpackError{Path: f.key, Err: err}
+		}
+		fv.Set(iv)
+	}
+
+	if remain != nil {
+		fv := fieldByIndex(v, p.remain)
+		for fv.Kind() == reflect.Pointer {
+			if fv.IsNil() {
+				fv.Set(reflect.New(fv.Type().Elem()))
+			}
+			fv = fv.Elem()
+		}
+		mv := reflect.MakeMapWithSize(fv.Type(), len(remain))
+		for k, raw := range remain {
+			ev := reflect.New(fv.Type().Elem())
+			if err := unmarshalValue(raw, ev.Interface(), o, false); err != nil {
+				return &UnpackError{Path: k, Err: err}
+			}
+			mv.SetMapIndex(reflect.ValueOf(k).Convert(fv.Type().Key()), ev.Elem())
+		}
+		fv.Set(mv)
+	}
+
+	return nil
+}
+
+// defaultValue returns the JSON value that populates the field with the value
+// of its default tag, which is either JSON (such as 10, true or ["a","b"]) or,
+// for fields populated from strings, the string itself.  Defaults of time
+// fields are parsed using the layout tag if there is one, or RFC 3339.
+func defaultValue(def string, sf reflect.StructField) (json.RawMessage, error) {
+
+	if t := indirect(sf.Type); t == timeType {
+		layout := sf.Tag.Get("layout")
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		tm, err := time.Parse(layout, def)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(tm)
+	}
+
+	v := reflect.New(sf.Type).Interface()
+	if err := json.Unmarshal([]byte(def), v); err == nil {
+		return json.RawMessage(def), nil
+	}
+
+	raw, _ := json.Marshal(def)
+	if err := json.Unmarshal(raw, v); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// setDefaults populates the fields of the Unpackable, or other pointer,
+// that have a default tag, unless they already have a value (for example
+// from a NamedUnpackableFactory)
+func setDefaults(r interface{}, o *options) error {
+
+	p := cachedPlan(reflect.TypeOf(r), o.tagName)
+	if p.err != nil || len(p.defs) == 0 {
+		return p.err
+	}
+
+	v := reflect.ValueOf(r).Elem()
+	for _, d := range p.defs {
+		fv := fieldByIndex(v, d.index)
+		if !fv.IsZero() {
+			continue
+		}
+		if err := json.Unmarshal(d.raw, fv.Addr().Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldKey returns the attribute name of the field that the attribute of the
+// JSON object populates, or "" if there is none
+func fieldKey(p *plan, k string, o *options) string {
+	if _, ok := p.index[k]; ok || o.caseSensitive {
+		return k
+	}
+	return p.keys[strings.ToLower(k)]
+}
+
+// take removes and returns the attribute of the JSON object with the key,
+// which as encoding/json matches an attribute of any case unless
+// WithCaseSensitiveFields is used, preferring an exact match
+func take(m map[string]json.RawMessage, key string, o *options) json.RawMessage {
+	k := key
+	if _, ok := m[k]; !ok && !o.caseSensitive {
+		for name := range m {
+			if strings.EqualFold(name, key) {
+				k = name
+				break
+			}
+		}
+	}
+	raw := m[k]
+	delete(m, k)
+	return raw
+}
+
+// unknownField returns the error that encoding/json returns for the first,
+// by name, of the attributes that do not match a field
+func unknownField(attrs map[string]json.RawMessage) error {
+	names := make([]string, 0, len(attrs))
+	for k := range attrs {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return fmt.Errorf("json: unknown field %q", names[0])
+}
+
+// unmarshal decodes the JSON object into v, rejecting attributes
+// that v does not have if WithStrictFields is used
+func unmarshal(b []byte, v interface{}, o *options) error {
+	return unmarshalValue(b, v, o, o.strictFields)
+}
+
+// unmarshalValue decodes the JSON value into v, rejecting attributes that v
+// does not have if strict, and decoding numbers as json.Number if
+// WithUseNumber is used
+func unmarshalValue(b []byte, v interface{}, o *options, strict bool) error {
+	if !strict && !o.useNumber {
+		return o.codec.Unmarshal(b, v)
+	}
+
+	d := o.codec.NewDecoder(bytes.NewReader(b))
+	if strict {
+		s, ok := d.(interface{ DisallowUnknownFields() })
+		if !ok {
+			return errors.New("codec decoder does not support DisallowUnknownFields")
+		}
+		s.DisallowUnknownFields()
+	}
+	if o.useNumber {
+		n, ok := d.(interface{ UseNumber() })
+		if !ok {
+			return errors.New("codec decoder does not support UseNumber")
+		}
+		n.UseNumber()
+	}
+
+	return d.Decode(v)
+}
+
+// parseTime returns the time from the first layout that successfully parses the string
+func parseTime(s string, layouts []string) (time.Time, error) {
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse %q as time using layouts %v", s, layouts)
+}
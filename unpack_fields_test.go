@@ -0,0 +1,565 @@
+package unpack
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type quote struct {
+	name     string
+	Date     time.Time  `json:"date" layout:"2006-01-02"`
+	Settled  *time.Time `json:"settled"`
+	Close    float64    `json:"close"`
+	Reported time.Time  `json:"reported"`
+}
+
+func (q *quote) SetName(name string) {
+	q.name = name
+}
+
+type quotef struct{}
+
+func (f quotef) New() Unpackable {
+	return new(quote)
+}
+
+func TestUnpackTimeLayouts(t *testing.T) {
+
+	b := []byte(`
+{
+	"history": {
+		"a": { "date": "2023-08-18", "settled": "18/08/2023", "close": 140.5, "reported": "18/08/2023" },
+		"b": { "date": "2023-08-21", "settled": null, "close": 141.25, "reported": "21/08/2023" }
+	}
+}
+	`)
+
+	u, err := Unpack(b, quotef{}, WithTimeLayouts("02/01/2006"))
+	if err != nil {
+		t.Fatalf("Unexpected parse failure: %v", err)
+	}
+
+	assert.Equal(t, 2, len(u))
+
+	for _, uu := range u {
+		q := uu.(*quote)
+		switch q.name {
+		case "a":
+			assert.Equal(t, time.Date(2023, 8, 18, 0, 0, 0, 0, time.UTC), q.Date)
+			assert.Equal(t, time.Date(2023, 8, 18, 0, 0, 0, 0, time.UTC), *q.Settled)
+			assert.Equal(t, time.Date(2023, 8, 18, 0, 0, 0, 0, time.UTC), q.Reported)
+			assert.Equal(t, 140.5, q.Close)
+		case "b":
+			assert.Equal(t, time.Date(2023, 8, 21, 0, 0, 0, 0, time.UTC), q.Date)
+			assert.Nil(t, q.Settled)
+			assert.Equal(t, 141.25, q.Close)
+		default:
+			t.Fatalf("Unexpected name: %s", q.name)
+		}
+	}
+
+	_, err = Unpack([]byte(`{ "history": { "a": { "date": "18/08/2023" } } }`), quotef{})
+	assert.NotNil(t, err)
+
+	_, err = Unpack([]byte(`{ "history": { "a": { "reported": "2023-08-18T10:00:00Z" } } }`), quotef{})
+	assert.Nil(t, err)
+}
+
+func TestCachedPlan(t *testing.T) {
+
+	typ := reflect.TypeOf(new(quote))
+
+	p := cachedPlan(typ, "")
+	assert.Equal(t, 3, len(p.times))
+	assert.Equal(t, newPlan(typ, ""), p)
+
+	c, ok := planCache.Load(planKey{t: typ})
+	assert.True(t, ok)
+	assert.True(t, p == c.(*plan))
+
+	assert.Equal(t, 0, len(cachedPlan(reflect.TypeOf(new(tt)), "").times))
+	assert.True(t, p != cachedPlan(typ, "mapstructure"))
+}
+
+type capital struct {
+	name    string
+	City    string                     `json:"city"`
+	Extra   map[string]json.RawMessage `json:"-" unpack:",remain"`
+	Founded time.Time                  `json:"founded" layout:"2006"`
+}
+
+func (c *capital) SetName(name string) {
+	c.name = name
+}
+
+type capitalf struct{}
+
+func (f capitalf) New() Unpackable {
+	return new(capital)
+}
+
+type looseCapital struct {
+	name  string
+	City  string                 `json:"city"`
+	Other map[string]interface{} `unpack:",remain"`
+}
+
+func (c *looseCapital) SetName(name string) {
+	c.name = name
+}
+
+type looseCapitalf struct{}
+
+func (f looseCapitalf) New() Unpackable {
+	return new(looseCapital)
+}
+
+type badRemain struct {
+	Other []string `unpack:",remain"`
+}
+
+func (b *badRemain) SetName(name string) {}
+
+type badRemainf struct{}
+
+func (f badRemainf) New() Unpackable {
+	return new(badRemain)
+}
+
+func TestUnpackRemain(t *testing.T) {
+
+	b := []byte(`
+{
+	"countries": {
+		"UK": { "City": "London", "founded": "0047", "mayor": "Sadiq Khan", "population": 9000000 },
+		"FR": { "city": "Paris" }
+	}
+}
+	`)
+
+	u, err := Unpack(b, capitalf{}, WithStrictFields())
+	if err != nil {
+		t.Fatalf("Unexpected parse failure: %v", err)
+	}
+
+	fr := u[0].(*capital)
+	assert.Equal(t, "Paris", fr.City)
+	assert.Nil(t, fr.Extra)
+
+	uk := u[1].(*capital)
+	assert.Equal(t, "London", uk.City)
+	assert.Equal(t, 47, uk.Founded.Year())
+	assert.Equal(t, map[string]json.RawMessage{
+		"mayor":      json.RawMessage(`"Sadiq Khan"`),
+		"population": json.RawMessage(`9000000`),
+	}, uk.Extra)
+
+	u, err = Unpack(b, looseCapitalf{})
+	if err != nil {
+		t.Fatalf("Unexpected parse failure: %v", err)
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"founded":    "0047",
+		"mayor":      "Sadiq Khan",
+		"population": float64(9000000),
+	}, u[1].(*looseCapital).Other)
+
+	_, err = Unpack(b, badRemainf{})
+	assert.NotNil(t, err)
+}
+
+func TestUnpackFieldCase(t *testing.T) {
+
+	b := []byte(`{ "capitals": { "UK": { "CITY": "London", "Founded": "0047", "mayor": "Khan" } } }`)
+
+	u, err := Unpack(b, capitalf{})
+	assert.Nil(t, err)
+	c := u[0].(*capital)
+	assert.Equal(t, "London", c.City)
+	assert.Equal(t, 47, c.Founded.Year())
+	assert.Equal(t, map[string]json.RawMessage{"mayor": json.RawMessage(`"Khan"`)}, c.Extra)
+
+	u, err = Unpack(b, capitalf{}, WithCaseSensitiveFields())
+	assert.Nil(t, err)
+	c = u[0].(*capital)
+	assert.Equal(t, "", c.City)
+	assert.True(t, c.Founded.IsZero())
+	assert.Equal(t, 3, len(c.Extra))
+
+	u, err = Unpack([]byte(`{ "history": { "a": { "Close": 140.5, "close": 141.5 } } }`), quotef{}, WithCaseSensitiveFields())
+	assert.Nil(t, err)
+	assert.Equal(t, 141.5, u[0].(*quote).Close)
+
+	_, err = Unpack([]byte(`{ "history": { "a": { "Close": 140.5 } } }`), quotef{}, WithCaseSensitiveFields(), WithStrictFields())
+	var ue *UnpackError
+	assert.ErrorAs(t, err, &ue)
+	assert.Equal(t, "Close", ue.Path)
+}
+
+type station struct {
+	name    string
+	Code    string    `json:"code" mapstructure:"station_code"`
+	Opened  time.Time `mapstructure:"opened_on" layout:"2006-01-02"`
+	Lines   []string  `json:"lines"`
+	Zone    int       `json:"zone" mapstructure:"-"`
+	Private string    `json:"-" mapstructure:"private"`
+}
+
+func (s *station) SetName(name string) {
+	s.name = name
+}
+
+type stationf struct{}
+
+func (f stationf) New() Unpackable {
+	return new(station)
+}
+
+func TestUnpackTagName(t *testing.T) {
+
+	b := []byte(`
+{
+	"stations": {
+		"KGX": { "station_code": "KGX", "OPENED_ON": "1852-10-14", "Lines": ["ECML"], "zone": 1, "private": "x" }
+	}
+}
+	`)
+
+	u, err := Unpack(b, stationf{}, WithTagName("mapstructure"))
+	assert.Nil(t, err)
+	assert.Equal(t, &station{
+		name:   "KGX",
+		Code:   "KGX",
+		Opened: time.Date(1852, 10, 14, 0, 0, 0, 0, time.UTC),
+		Lines:  []string{"ECML"},
+	}, u[0])
+
+	_, err = Unpack(b, stationf{}, WithTagName("mapstructure"), WithStrictFields())
+	var ue *UnpackError
+	assert.ErrorAs(t, err, &ue)
+	assert.Equal(t, "private", ue.Path)
+
+	u, err = Unpack(b, stationf{}, WithTagName("json"))
+	assert.Nil(t, err)
+	assert.Equal(t, &station{name: "KGX", Lines: []string{"ECML"}, Zone: 1}, u[0])
+}
+
+type Audited struct {
+	CreatedAt time.Time  `json:"created_at" layout:"2006-01-02"`
+	UpdatedAt *time.Time `json:"updated_at" layout:"2006-01-02"`
+}
+
+type versioned struct {
+	Version int `json:"version"`
+}
+
+type account struct {
+	*Audited
+	versioned
+	name    string
+	Owner   string                     `json:"owner"`
+	Version string                     `json:"version"`
+	Extra   map[string]json.RawMessage `unpack:",remain"`
+}
+
+func (a *account) SetName(name string) {
+	a.name = name
+}
+
+type accountf struct{}
+
+func (f accountf) New() Unpackable {
+	return new(account)
+}
+
+func TestUnpackEmbedded(t *testing.T) {
+
+	b := []byte(`
+{
+	"accounts": {
+		"A1": { "owner": "Ann", "created_at": "2023-08-18", "updated_at": "2023-08-21", "version": "v2", "region": "EU" },
+		"A2": { "owner": "Bob" }
+	}
+}
+	`)
+
+	u, err := Unpack(b, accountf{})
+	assert.Nil(t, err)
+
+	updated := time.Date(2023, 8, 21, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, &account{
+		Audited: &Audited{CreatedAt: time.Date(2023, 8, 18, 0, 0, 0, 0, time.UTC), UpdatedAt: &updated},
+		name:    "A1",
+		Owner:   "Ann",
+		Version: "v2",
+		Extra:   map[string]json.RawMessage{"region": json.RawMessage(`"EU"`)},
+	}, u[0])
+	assert.Equal(t, &account{name: "A2", Owner: "Bob"}, u[1])
+
+	s := ExplainPlan[*account]()
+	assert.Contains(t, s, "  Audited            -                        embedded, fields promoted\n")
+	assert.Contains(t, s, `  Audited.CreatedAt  <- "created_at"          time, layout "2006-01-02"`)
+	assert.Contains(t, s, "  versioned.Version  -                        not populated (hidden by a shallower field)\n")
+}
+
+type ledger struct {
+	name    string
+	ID      int64                  `json:"id"`
+	Balance json.Number            `json:"balance"`
+	Meta    interface{}            `json:"meta"`
+	Extra   map[string]interface{} `unpack:",remain"`
+}
+
+func (l *ledger) SetName(name string) {
+	l.name = name
+}
+
+type ledgerf struct{}
+
+func (f ledgerf) New() Unpackable {
+	return new(ledger)
+}
+
+func TestUnpackUseNumber(t *testing.T) {
+
+	b := []byte(`{ "ledgers": { "L1": { "id": 9007199254740993, "balance": 12345678901234567890, "meta": 9007199254740993, "seq": 9007199254740995 } } }`)
+
+	u, err := Unpack(b, ledgerf{}, WithUseNumber())
+	assert.Nil(t, err)
+	assert.Equal(t, &ledger{
+		name:    "L1",
+		ID:      9007199254740993,
+		Balance: "12345678901234567890",
+		Meta:    json.Number("9007199254740993"),
+		Extra:   map[string]interface{}{"seq": json.Number("9007199254740995")},
+	}, u[0])
+
+	u, err = Unpack(b, ledgerf{}, WithUseNumber(), WithStrictFields())
+	assert.Nil(t, err)
+	assert.Equal(t, json.Number("9007199254740993"), u[0].(*ledger).Meta)
+
+	u, err = Unpack(b, ledgerf{})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(9007199254740993), u[0].(*ledger).ID)
+	assert.Equal(t, float64(9007199254740992), u[0].(*ledger).Meta)
+}
+
+type price struct {
+	name    string
+	Open    float64 `json:"1. open"`
+	Volume  *int64  `json:"5. volume"`
+	Symbol  string  `json:"symbol"`
+	Split   bool    `json:"split"`
+	Closed  bool    `json:"closed"`
+	Session int     `json:"session"`
+}
+
+func (p *price) SetName(name string) {
+	p.name = name
+}
+
+type pricef struct{}
+
+func (f pricef) New() Unpackable {
+	return new(price)
+}
+
+func TestUnpackWeakTyping(t *testing.T) {
+
+	b := []byte(`{ "Time Series (Daily)": { "2023-08-18": { "1. open": "140.50", "5. volume": " 1200 ", "SYMBOL": 700, "split": "true", "closed": 1, "session": true } } }`)
+
+	_, err := Unpack(b, pricef{})
+	assert.NotNil(t, err)
+
+	u, err := Unpack(b, pricef{}, WithWeakTyping())
+	assert.Nil(t, err)
+
+	volume := int64(1200)
+	assert.Equal(t, &price{name: "2023-08-18", Open: 140.5, Volume: &volume, Symbol: "700", Split: true, Closed: true, Session: 1}, u[0])
+
+	_, err = Unpack([]byte(`{ "prices": { "a": { "1. open": "n/a" } } }`), pricef{}, WithWeakTyping())
+	var ue *UnpackError
+	assert.ErrorAs(t, err, &ue)
+	assert.Equal(t, "1. open", ue.Path)
+
+	u, err = Unpack([]byte(`{ "prices": { "a": { "SYMBOL": 700 } } }`), pricef{}, WithWeakTyping(), WithCaseSensitiveFields())
+	assert.Nil(t, err)
+	assert.Equal(t, "", u[0].(*price).Symbol)
+
+	assert.Equal(t, json.RawMessage(`"x"`), weaken(json.RawMessage(`"x"`), reflect.TypeOf(0)))
+	assert.Equal(t, json.RawMessage(`"+1"`), weaken(json.RawMessage(`"+1"`), reflect.TypeOf(0)))
+	assert.Equal(t, json.RawMessage(`null`), weaken(json.RawMessage(`null`), reflect.TypeOf("")))
+	assert.Equal(t, json.RawMessage(`"12"`), weaken(json.RawMessage(`"12"`), reflect.TypeOf(json.RawMessage{})))
+}
+
+type busRoute struct {
+	name   string
+	Stops  *[]string                   `json:"stops"`
+	Fares  *map[string]int             `json:"fares"`
+	Legs   **[]int                     `json:"legs"`
+	Opened *time.Time                  `json:"opened" layout:"2006"`
+	Extra  *map[string]json.RawMessage `unpack:",remain"`
+}
+
+func (r *busRoute) SetName(name string) {
+	r.name = name
+}
+
+type busRoutef struct{}
+
+func (f busRoutef) New() Unpackable {
+	return new(busRoute)
+}
+
+func TestUnpackPointerComposites(t *testing.T) {
+
+	b := []byte(`{ "routes": { "R1": { "stops": ["A", "B"], "fares": { "adult": 3 }, "legs": [1, 2], "opened": "1863", "operator": "TfL" }, "R2": { "stops": null } } }`)
+
+	for _, opts := range [][]Option{nil, {WithWeakTyping()}, {WithCaseSensitiveFields()}, {WithStrictFields()}} {
+		u, err := Unpack(b, busRoutef{}, opts...)
+		if !assert.Nil(t, err) {
+			continue
+		}
+
+		r := u[0].(*busRoute)
+		assert.Equal(t, []string{"A", "B"}, *r.Stops)
+		assert.Equal(t, map[string]int{"adult": 3}, *r.Fares)
+		assert.Equal(t, []int{1, 2}, **r.Legs)
+		assert.Equal(t, 1863, r.Opened.Year())
+		assert.Equal(t, map[string]json.RawMessage{"operator": json.RawMessage(`"TfL"`)}, *r.Extra)
+
+		r = u[1].(*busRoute)
+		assert.Nil(t, r.Stops)
+		assert.Nil(t, r.Extra)
+	}
+}
+
+type setting struct {
+	name     string
+	Currency string        `json:"currency" default:"GBP"`
+	Retries  int           `json:"retries" default:"3"`
+	Enabled  *bool         `json:"enabled" default:"true"`
+	Hosts    []string      `json:"hosts" default:"[\"localhost\"]"`
+	Start    time.Time     `json:"start" layout:"2006-01-02" default:"2023-01-01"`
+	Timeout  time.Duration `json:"timeout"`
+}
+
+func (s *setting) SetName(name string) {
+	s.name = name
+}
+
+type settingf struct{}
+
+func (f settingf) New() Unpackable {
+	return new(setting)
+}
+
+func (f settingf) NewNamed(name string) Unpackable {
+	if name == "eu" {
+		return &setting{Currency: "EUR"}
+	}
+	return f.New()
+}
+
+type badDefault struct {
+	Retries int `json:"retries" default:"three"`
+}
+
+func (b *badDefault) SetName(name string) {}
+
+type badDefaultf struct{}
+
+func (f badDefaultf) New() Unpackable {
+	return new(badDefault)
+}
+
+func TestUnpackDefaults(t *testing.T) {
+
+	b := []byte(`
+{
+	"settings": {
+		"uk": { "retries": 5, "hosts": ["a"] },
+		"eu": { "enabled": false, "start": "2024-06-01" }
+	}
+}
+	`)
+
+	u, err := Unpack(b, settingf{})
+	assert.Nil(t, err)
+
+	yes, no := true, false
+	assert.Equal(t, &setting{name: "eu", Currency: "EUR", Retries: 3, Enabled: &no, Hosts: []string{"localhost"}, Start: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)}, u[0])
+	assert.Equal(t, &setting{name: "uk", Currency: "GBP", Retries: 5, Enabled: &yes, Hosts: []string{"a"}, Start: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}, u[1])
+
+	// Defaults are not shared between instances
+	u, err = Unpack([]byte(`{ "settings": { "a": {}, "b": {} } }`), settingf{})
+	assert.Nil(t, err)
+	u[0].(*setting).Hosts[0] = "changed"
+	assert.Equal(t, []string{"localhost"}, u[1].(*setting).Hosts)
+
+	var s setting
+	assert.Nil(t, DecodeMap(map[string]interface{}{"retries": 1}, &s))
+	assert.Equal(t, "GBP", s.Currency)
+	assert.Equal(t, 1, s.Retries)
+
+	_, err = Unpack(b, badDefaultf{})
+	assert.NotNil(t, err)
+}
+
+func TestDecodeMap(t *testing.T) {
+
+	m := map[string]interface{}{
+		"station_code": "KGX",
+		"opened_on":    "1852-10-14",
+		"lines":        []string{"ECML"},
+	}
+
+	var s station
+	err := DecodeMap(m, &s, WithTagName("mapstructure"))
+	assert.Nil(t, err)
+	assert.Equal(t, station{Code: "KGX", Opened: time.Date(1852, 10, 14, 0, 0, 0, 0, time.UTC), Lines: []string{"ECML"}}, s)
+
+	var p price
+	err = DecodeMap(map[string]interface{}{"1. open": "140.50", "split": "true"}, &p, WithWeakTyping())
+	assert.Nil(t, err)
+	assert.Equal(t, price{Open: 140.5, Split: true}, p)
+
+	err = DecodeMap(map[string]interface{}{"unknown": 1}, &p, WithStrictFields())
+	assert.NotNil(t, err)
+
+	var g map[string]interface{}
+	err = DecodeMap(map[string]interface{}{"a": 1}, &g)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"a": float64(1)}, g)
+
+	err = DecodeMap(m, s)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkUnpackTimeLayouts(b *testing.B) {
+
+	var sb strings.Builder
+	sb.WriteString(`{ "history": {`)
+	for i := 0; i < 1000; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		fmt.Fprintf(&sb, `"%d": { "date": "2023-08-18", "close": %d, "reported": "18/08/2023" }`, i, i)
+	}
+	sb.WriteString(`} }`)
+	data := []byte(sb.String())
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Unpack(data, quotef{}, WithTimeLayouts("02/01/2006")); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package unpack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Group holds the Unpackables populated from one of the named maps nested
+// within the JSON object
+type Group struct {
+	Name  string
+	Items []Unpackable
+}
+
+// GroupNamed is implemented by Unpackables that retain the name of the
+// Group they were populated within.  SetGroupName is called after SetName.
+type GroupNamed interface {
+	SetGroupName(name string)
+}
+
+// UnpackGroups returns the Unpackables from JSON whose named map holds
+// further named maps of Unpackables (such as region, then country), as a
+// Group for each of the outer names, in ascending order of those names.
+// Of the options, WithSection (or WithSectionPath), WithSkipSections,
+// WithSectionHandler, WithUnknownSections, WithCaptureExtras,
+// WithErrorSections, WithMaxBytes and WithRecorder apply to the JSON object,
+// and the others to the Unpackables of each Group (WithOrderFrom is not
+// supported).  If a Group's Unpackables are returned together with an error
+// (see WithContinueOnError), the Group is retained, and the errors of all the
+// Groups are returned together (as an Errors if there is more than one);
+// otherwise the error is returned identifying the Group.
+func UnpackGroups[F UnpackableFactory](ctx context.Context, b []byte, fact F, opts ...Option) ([]Group, error) {
+
+	o := newOptions(opts)
+
+	if o.orderFrom != "" {
+		return nil, errors.New("WithOrderFrom is not supported")
+	}
+
+	if o.recorder != nil {
+		if err := o.recorder.Record(b); err != nil {
+			return nil, err
+		}
+	}
+
+	outer := newOptions(nil)
+	outer.codec = o.codec
+	outer.section = o.section
+	outer.sectionPath = o.sectionPath
+	outer.skipped = o.skipped
+	outer.handlers = o.handlers
+	outer.unknownSections = o.unknownSections
+	outer.extras = o.extras
+	outer.errorSections = o.errorSections
+	outer.maxBytes = o.maxBytes
+
+	p, err := prepare(ctx, b, outer)
+	if err != nil {
+		return nil, err
+	}
+
+	errs := p.unknown
+	groups := make([]Group, len(p.names))
+	for i, name := range p.names {
+		gb, err := o.codec.Marshal(map[string]json.RawMessage{name: p.items[name]})
+		if err != nil {
+			return nil, err
+		}
+
+		items, err := UnpackContext(ctx, gb, fact, append(opts[:len(opts):len(opts)], withinGroup(name))...)
+		if err != nil && items == nil {
+			return nil, fmt.Errorf("group %q: %w", name, err)
+		}
+		errs = join(errs, err)
+
+		for _, item := range items {
+			if gn, ok := item.(GroupNamed); ok {
+				gn.SetGroupName(name)
+			}
+		}
+
+		groups[i] = Group{Name: name, Items: items}
+	}
+
+	return groups, errs
+}
+
+// withinGroup replaces the options that UnpackGroups applies to the JSON
+// object, so that the Group's named map can be unpacked
+func withinGroup(name string) Option {
+	return func(o *options) {
+		withinPayload(o)
+		o.section = name
+		o.sectionPath = nil
+	}
+}
+
+// withinPayload clears the options that apply to the JSON object as a whole,
+// once the caller has applied them
+func withinPayload(o *options) {
+	o.skipped = nil
+	o.handlers = nil
+	o.unknownSections = UnknownSectionIgnore
+	o.extras = nil
+	o.errorSections = nil
+	o.maxBytes = 0
+	o.recorder = nil
+}
@@ -0,0 +1,92 @@
+package unpack
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type place struct {
+	Name    string
+	Region  string
+	Capital string `json:"capital"`
+}
+
+func (p *place) SetName(name string) {
+	p.Name = name
+}
+
+func (p *place) SetGroupName(name string) {
+	p.Region = name
+}
+
+type placef struct{}
+
+func (placef) New() Unpackable {
+	return new(place)
+}
+
+func TestUnpackGroups(t *testing.T) {
+
+	b := []byte(`
+{
+	"Meta Data": { "source": "atlas" },
+	"regions": {
+		"Europe": {
+			"United Kingdom": { "capital": "London" },
+			"France": { "capital": "Paris" }
+		},
+		"Asia": {
+			"Japan": { "capital": "Tokyo" }
+		}
+	}
+}
+	`)
+
+	ctx := context.Background()
+
+	groups, err := UnpackGroups(ctx, b, placef{}, WithSection("regions"), WithOrdering(OrderingDescending))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(groups))
+
+	assert.Equal(t, "Asia", groups[0].Name)
+	assert.Equal(t, 1, len(groups[0].Items))
+	assert.Equal(t, &place{Name: "Japan", Region: "Asia", Capital: "Tokyo"}, groups[0].Items[0])
+
+	// Options other than those locating the groups apply within each group
+	assert.Equal(t, "Europe", groups[1].Name)
+	assert.Equal(t, "United Kingdom", groups[1].Items[0].(*place).Name)
+	assert.Equal(t, "Europe", groups[1].Items[1].(*place).Region)
+
+	groups, err = UnpackGroups(ctx, b, placef{}, WithSkipSections("Meta Data"), WithLimit(1))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(groups[1].Items))
+
+	_, err = UnpackGroups(ctx, []byte(`{ "regions": { "Europe": { "France": { "capital": 1 } } } }`), placef{})
+	var ue *UnpackError
+	assert.ErrorAs(t, err, &ue)
+	assert.Equal(t, "France", ue.Name)
+
+	_, err = UnpackGroups(ctx, b, placef{}, WithSection("regions"), WithOrderFrom("order"))
+	assert.NotNil(t, err)
+
+	_, err = UnpackGroups(ctx, []byte(`{ "regions": { "Europe": [] } }`), placef{})
+	assert.NotNil(t, err)
+
+	// Groups returned with errors are retained, and the errors joined
+	groups, err = UnpackGroups(ctx, []byte(`
+{
+	"regions": {
+		"Europe": { "France": { "capital": 1 }, "Spain": { "capital": "Madrid" } },
+		"Asia": { "Japan": { "capital": 2 } }
+	}
+}
+	`), placef{}, WithContinueOnError())
+	assert.Equal(t, 2, len(groups))
+	assert.Equal(t, 0, len(groups[0].Items))
+	assert.Equal(t, "Spain", groups[1].Items[0].(*place).Name)
+	var errs Errors
+	assert.ErrorAs(t, err, &errs)
+	assert.Equal(t, 2, len(errs))
+}
@@ -0,0 +1,55 @@
+package unpack
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// DecodeHook converts the value v, decoded from an attribute of a JSON object
+// as by json.Unmarshal into an interface{} (so that from is the type of a
+// string, float64, bool, []interface{} or map[string]interface{}, or nil for
+// a JSON null), for the field of type to.  The value returned is used if it
+// is assignable to the field, and otherwise is encoded as JSON and decoded
+// into the field.  A hook should return v unchanged if it does not apply.
+type DecodeHook func(from, to reflect.Type, v interface{}) (interface{}, error)
+
+// hookedValue is a value returned by a DecodeHook that is set directly
+// on the field with the index
+type hookedValue struct {
+	index []int
+	v     reflect.Value
+}
+
+// applyHooks passes the JSON value through the decode hooks, in the order
+// they were provided, returning either the value to be set on the field of
+// type t or, if the result cannot be assigned to it, the JSON to decode
+func applyHooks(raw json.RawMessage, t reflect.Type, o *options) (json.RawMessage, reflect.Value, error) {
+
+	var orig interface{}
+	if err := unmarshalValue(raw, &orig, o, false); err != nil {
+		return nil, reflect.Value{}, err
+	}
+
+	v := orig
+	for _, hook := range o.decodeHooks {
+		var err error
+		if v, err = hook(reflect.TypeOf(v), t, v); err != nil {
+			return nil, reflect.Value{}, err
+		}
+	}
+
+	if v != nil && reflect.TypeOf(v).AssignableTo(t) {
+		return nil, reflect.ValueOf(v), nil
+	}
+
+	// Retain the original JSON if no hook applied, so that numbers keep their precision
+	if reflect.DeepEqual(v, orig) {
+		return raw, reflect.Value{}, nil
+	}
+
+	b, err := o.codec.Marshal(v)
+	if err != nil {
+		return nil, reflect.Value{}, err
+	}
+	return b, reflect.Value{}, nil
+}
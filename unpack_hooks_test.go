@@ -0,0 +1,85 @@
+package unpack
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type cents int64
+
+type journey struct {
+	name     string
+	Duration time.Duration `json:"duration"`
+	Fare     cents         `json:"fare"`
+	Stops    []string      `json:"stops"`
+	ID       int64         `json:"id"`
+}
+
+func (j *journey) SetName(name string) {
+	j.name = name
+}
+
+type journeyf struct{}
+
+func (f journeyf) New() Unpackable {
+	return new(journey)
+}
+
+func TestUnpackDecodeHook(t *testing.T) {
+
+	duration := func(from, to reflect.Type, v interface{}) (interface{}, error) {
+		if s, ok := v.(string); ok && to == reflect.TypeOf(time.Duration(0)) {
+			return time.ParseDuration(s)
+		}
+		return v, nil
+	}
+
+	fare := func(from, to reflect.Type, v interface{}) (interface{}, error) {
+		s, ok := v.(string)
+		if !ok || to != reflect.TypeOf(cents(0)) {
+			return v, nil
+		}
+		whole, frac, _ := strings.Cut(s, ".")
+		n, err := strconv.ParseInt(whole+frac, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		// Returned as an int64, which is not assignable to cents, so re-encoded
+		return n, nil
+	}
+
+	stops := func(from, to reflect.Type, v interface{}) (interface{}, error) {
+		if s, ok := v.(string); ok && to.Kind() == reflect.Slice {
+			return strings.Split(s, ","), nil
+		}
+		return v, nil
+	}
+
+	b := []byte(`{ "journeys": { "J1": { "duration": "1h30m", "FARE": "12.34", "stops": "A,B", "id": 9007199254740993 } } }`)
+
+	u, err := Unpack(b, journeyf{}, WithDecodeHook(duration), WithDecodeHook(fare), WithDecodeHook(stops))
+	assert.Nil(t, err)
+	assert.Equal(t, &journey{name: "J1", Duration: 90 * time.Minute, Fare: 1234, Stops: []string{"A", "B"}, ID: 9007199254740993}, u[0])
+
+	_, err = Unpack(b, journeyf{}, WithDecodeHook(duration))
+	assert.NotNil(t, err)
+
+	_, err = Unpack([]byte(`{ "journeys": { "J1": { "fare": "n/a" } } }`), journeyf{}, WithDecodeHook(fare))
+	var ue *UnpackError
+	assert.ErrorAs(t, err, &ue)
+	assert.Equal(t, "fare", ue.Path)
+
+	fail := errors.New("fail")
+	_, err = Unpack(b, journeyf{}, WithDecodeHook(func(from, to reflect.Type, v interface{}) (interface{}, error) {
+		return nil, fail
+	}))
+	assert.ErrorIs(t, err, fail)
+
+	assert.Contains(t, ExplainPlan[*journey](WithDecodeHook(duration)), "decode hooks")
+}
@@ -0,0 +1,90 @@
+package unpack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// IndexEntry holds the byte offsets of an Unpackable's JSON object within
+// the JSON from which the Index was built
+type IndexEntry struct {
+	Name  string `json:"name"`
+	Start int64  `json:"start"`
+	End   int64  `json:"end"`
+}
+
+// Bytes returns the Unpackable's JSON object from the JSON the Index was built from
+func (e IndexEntry) Bytes(b []byte) []byte {
+	return b[e.Start:e.End]
+}
+
+// Index records where the JSON object of each Unpackable is located within
+// the JSON, in the order they appear.  It can be persisted alongside the JSON
+// (for example, using encoding/json), so that individual Unpackables can later
+// be populated directly from the original bytes.
+type Index []IndexEntry
+
+// Find returns the entry with the specified name.  If the name appears
+// more than once, the last entry is returned, consistent with Unpack.
+func (ix Index) Find(name string) (IndexEntry, bool) {
+	for i := len(ix) - 1; i >= 0; i-- {
+		if ix[i].Name == name {
+			return ix[i], true
+		}
+	}
+	return IndexEntry{}, false
+}
+
+// BuildIndex returns the Index of the JSON, built by scanning its tokens.
+// Of the options, only WithOrderFrom, WithSection (or WithSectionPath),
+// WithSkipSections and WithMaxBytes are used.
+func BuildIndex(ctx context.Context, b []byte, opts ...Option) (Index, error) {
+
+	var ix Index
+
+	err := scanNames(ctx, b, newOptions(opts), func(d *json.Decoder, name string) error {
+		start := d.InputOffset()
+		for start < int64(len(b)) && isSeparator(b[start]) {
+			start++
+		}
+
+		if err := skipValue(d); err != nil {
+			return err
+		}
+
+		ix = append(ix, IndexEntry{Name: name, Start: start, End: d.InputOffset()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ix, nil
+}
+
+// isSeparator returns true for the bytes that may appear between
+// an attribute name and its value
+func isSeparator(c byte) bool {
+	switch c {
+	case ' ', '\t', '\r', '\n', ':':
+		return true
+	}
+	return false
+}
+
+// UnpackIndexed returns the Unpackable with the specified name, populated
+// directly from its bytes within the JSON using an Index previously built from it.
+// If the name is not in the Index, an error wrapping ErrNameNotFound is returned.
+func UnpackIndexed[F UnpackableFactory](b []byte, ix Index, fact F, name string, opts ...Option) (Unpackable, error) {
+
+	e, ok := ix.Find(name)
+	if !ok {
+		return nil, fmt.Errorf("%q: %w", name, ErrNameNotFound)
+	}
+	if e.Start < 0 || e.End > int64(len(b)) || e.Start > e.End {
+		return nil, fmt.Errorf("%q: index entry out of range", name)
+	}
+
+	return populate(newFunc(fact), name, nil, map[string]json.RawMessage{name: e.Bytes(b)}, newOptions(opts))
+}
@@ -0,0 +1,52 @@
+package unpack
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildIndex(t *testing.T) {
+
+	b := []byte(`
+{
+	"order": ["UK"],
+	"countries": {
+		"UK" : { "capital": "London", "population": { "2023": 66000000 } },
+		"FR":{"capital":"Paris"},
+		"DE":
+			null
+	}
+}
+	`)
+
+	ix, err := BuildIndex(context.Background(), b, WithOrderFrom("order"))
+	if err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+
+	assert.Equal(t, 3, len(ix))
+	assert.Equal(t, `{ "capital": "London", "population": { "2023": 66000000 } }`, string(ix[0].Bytes(b)))
+	assert.Equal(t, `{"capital":"Paris"}`, string(ix[1].Bytes(b)))
+	assert.Equal(t, `null`, string(ix[2].Bytes(b)))
+
+	// Index can be persisted
+	p, err := json.Marshal(ix)
+	assert.Nil(t, err)
+
+	var loaded Index
+	assert.Nil(t, json.Unmarshal(p, &loaded))
+	assert.Equal(t, ix, loaded)
+
+	u, err := UnpackIndexed(b, loaded, countryf{}, "UK")
+	assert.Nil(t, err)
+	assert.Equal(t, &country{name: "UK", Capital: "London", Population: map[string]int{"2023": 66000000}}, u)
+
+	_, err = UnpackIndexed(b, loaded, countryf{}, "US")
+	assert.ErrorIs(t, err, ErrNameNotFound)
+
+	_, err = UnpackIndexed(b[:10], loaded, countryf{}, "UK")
+	assert.NotNil(t, err)
+}
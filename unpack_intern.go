@@ -0,0 +1,83 @@
+package unpack
+
+import (
+	"reflect"
+	"sync"
+)
+
+// interner holds a single copy of each distinct string value, shared by
+// the Unpackables of a call to Unpack (see WithStringInterning)
+type interner struct {
+	mu sync.Mutex
+	m  map[string]string
+}
+
+func newInterner() *interner {
+	return &interner{
+		m: map[string]string{},
+	}
+}
+
+// intern returns the copy of the string held by the interner
+func (in *interner) intern(s string) string {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	if t, ok := in.m[s]; ok {
+		return t
+	}
+	in.m[s] = s
+	return s
+}
+
+// walk replaces each settable string reachable from v, including map
+// keys and values, with its interned copy
+func (in *interner) walk(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.String:
+		if v.CanSet() {
+			v.SetString(in.intern(v.String()))
+		}
+	case reflect.Pointer:
+		if !v.IsNil() {
+			in.walk(v.Elem())
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		if e := v.Elem(); e.Kind() == reflect.String && v.CanSet() {
+			v.Set(reflect.ValueOf(in.intern(e.String())).Convert(e.Type()))
+		} else {
+			in.walk(e)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			in.walk(v.Field(i))
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			in.walk(v.Index(i))
+		}
+	case reflect.Map:
+		if v.IsNil() || !v.CanInterface() {
+			return
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			// Map entries are not settable, so are copied, interned and replaced;
+			// replacing the entry also replaces its key with the interned copy
+			k := reflect.New(v.Type().Key()).Elem()
+			k.Set(iter.Key())
+			e := reflect.New(v.Type().Elem()).Elem()
+			e.Set(iter.Value())
+
+			in.walk(k)
+			in.walk(e)
+			v.SetMapIndex(k, e)
+		}
+	}
+}
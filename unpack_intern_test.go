@@ -0,0 +1,63 @@
+package unpack
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type dividend struct {
+	name     string
+	Amount   string                 `json:"amount"`
+	Currency *string                `json:"currency"`
+	Notes    []string               `json:"notes"`
+	Extra    map[string]interface{} `json:"extra"`
+}
+
+func (d *dividend) SetName(name string) {
+	d.name = name
+}
+
+type dividendf struct{}
+
+func (f dividendf) New() Unpackable {
+	return new(dividend)
+}
+
+// data returns the address of the bytes of the string
+func data(s string) uintptr {
+	return (*reflect.StringHeader)(unsafe.Pointer(&s)).Data
+}
+
+func TestUnpackStringInterning(t *testing.T) {
+
+	b := []byte(`
+{
+	"dividends": {
+		"2023-01-02": { "amount": "0.0000", "currency": "USD", "notes": ["none"], "extra": { "source": "feed", "tags": ["none"] } },
+		"2023-01-03": { "amount": "0.0000", "currency": "USD", "notes": ["none"], "extra": { "source": "feed", "tags": ["none"] } }
+	}
+}
+	`)
+
+	u, err := Unpack(b, dividendf{}, WithStringInterning())
+	assert.Nil(t, err)
+
+	d0, d1 := u[0].(*dividend), u[1].(*dividend)
+	assert.Equal(t, "0.0000", d1.Amount)
+	assert.Equal(t, data(d0.Amount), data(d1.Amount))
+	assert.Equal(t, data(*d0.Currency), data(*d1.Currency))
+	assert.Equal(t, data(d0.Notes[0]), data(d1.Notes[0]))
+	assert.Equal(t, data(d0.Notes[0]), data(d1.Extra["tags"].([]interface{})[0].(string)))
+	assert.Equal(t, data(d0.Extra["source"].(string)), data(d1.Extra["source"].(string)))
+
+	for k0 := range d0.Extra {
+		for k1 := range d1.Extra {
+			if k0 == k1 {
+				assert.Equal(t, data(k0), data(k1))
+			}
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package unpack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Keys returns the names of the Unpackables within the JSON object, in the
+// order that Unpack would return them given the same options, by scanning its
+// tokens without building maps or populating any Unpackables.  This allows
+// work to be planned before deciding whether to unpack the JSON object.
+// Of the options, those locating the Unpackables (see Count), and those
+// relating to their names, order and selection, are used.
+func Keys(ctx context.Context, b []byte, opts ...Option) ([]string, error) {
+
+	o := newOptions(opts)
+
+	var (
+		names []string
+		seen  = map[string]bool{}
+	)
+
+	err := scanNames(ctx, b, o, func(d *json.Decoder, name string) error {
+		if seen[name] {
+			if o.duplicateNames == DuplicateNameError {
+				return fmt.Errorf("duplicate name %q", name)
+			}
+		} else {
+			seen[name] = true
+			names = append(names, name)
+			if o.maxItems > 0 && len(names) > o.maxItems {
+				return errStopScan
+			}
+		}
+		return skipValue(d)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if o.maxItems > 0 && len(names) > o.maxItems {
+		return nil, &LimitError{Limit: "items", Max: o.maxItems, Actual: len(names)}
+	}
+
+	var order []string
+	if o.orderFrom != "" {
+		if err := UnmarshalSection(ctx, b, o.orderFrom, &order, opts...); err != nil {
+			return nil, err
+		}
+	}
+
+	names, _, err = arrangeNames(names, func(name string) bool { return seen[name] }, order, o)
+	if err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
+
+// Count returns the number of Unpackables within the JSON object by scanning
+// its tokens, without building maps or populating any Unpackables.
+// A name that is duplicated is counted each time it appears.
+// Of the options, only WithOrderFrom, WithSection (or WithSectionPath),
+// WithSkipSections and WithMaxBytes are used.
+func Count(ctx context.Context, b []byte, opts ...Option) (int, error) {
+
+	count := 0
+
+	err := scanNames(ctx, b, newOptions(opts), func(d *json.Decoder, name string) error {
+		count++
+		return skipValue(d)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// Contains reports whether the JSON object includes an Unpackable with the
+// specified name, by scanning its tokens and stopping once the name is found.
+// Of the options, only WithOrderFrom, WithSection (or WithSectionPath),
+// WithSkipSections and WithMaxBytes are used.
+func Contains(ctx context.Context, b []byte, name string, opts ...Option) (bool, error) {
+
+	found := false
+
+	err := scanNames(ctx, b, newOptions(opts), func(d *json.Decoder, n string) error {
+		if n == name {
+			found = true
+			return errStopScan
+		}
+		return skipValue(d)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return found, nil
+}
+
+// UnmarshalSection decodes the value of the named top level attribute of the
+// JSON object into v, skipping the tokens of any other attributes, such as
+// the section holding the Unpackables, without decoding them.  This allows
+// a small section, such as metadata describing the freshness of the JSON, to
+// be read quickly, and the Unpackables only unpacked later if required (see
+// WithSection).  Of the options, only WithMaxBytes and WithCodec are used.
+func UnmarshalSection(ctx context.Context, b []byte, name string, v interface{}, opts ...Option) error {
+	return UnmarshalSections(ctx, b, []string{name}, v, opts...)
+}
+
+// UnmarshalSections is as UnmarshalSection, but decodes the values of each
+// of the named top level attributes into v in turn, for JSON that splits its
+// metadata across several sections (such as "Meta Data" and "Rate Limits").
+// The values are merged as by successive calls to json.Unmarshal, so that an
+// attribute present in more than one section takes its value from the last.
+// An error is returned if any of the sections is not present.
+func UnmarshalSections(ctx context.Context, b []byte, names []string, v interface{}, opts ...Option) error {
+
+	o := newOptions(opts)
+
+	if o.maxBytes > 0 && len(b) > o.maxBytes {
+		return &LimitError{Limit: "bytes", Max: o.maxBytes, Actual: len(b)}
+	}
+
+	raws := make(map[string]json.RawMessage, len(names))
+	for _, name := range names {
+		raws[name] = nil
+	}
+	remaining := len(raws)
+
+	d := json.NewDecoder(bytes.NewReader(b))
+
+	if t, err := d.Token(); err != nil {
+		return err
+	} else if t != json.Delim('{') {
+		return errNotObject
+	}
+
+	for remaining > 0 && d.More() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		t, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		name, _ := t.(string)
+		if raw, ok := raws[name]; !ok || raw != nil {
+			if err := skipValue(d); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var raw json.RawMessage
+		if err := d.Decode(&raw); err != nil {
+			return err
+		}
+		raws[name] = raw
+		remaining--
+	}
+
+	for _, name := range names {
+		if raws[name] == nil {
+			return fmt.Errorf("section %q not found", name)
+		}
+	}
+
+	for _, name := range names {
+		if err := o.codec.Unmarshal(raws[name], v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
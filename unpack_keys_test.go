@@ -0,0 +1,261 @@
+package unpack
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeys(t *testing.T) {
+
+	b := []byte(`
+{
+	"order": ["US"],
+	"countries": {
+		"UK": {},
+		"FR": {},
+		"US": {},
+		"DE": {}
+	}
+}
+	`)
+
+	type tc struct {
+		opts  []Option
+		names []string
+	}
+
+	tests := []tc{
+		{
+			opts:  []Option{WithOrderFrom("order")},
+			names: []string{"US", "DE", "FR", "UK"},
+		},
+		{
+			opts:  []Option{WithOrderFrom("order"), WithOrdering(OrderingDescending)},
+			names: []string{"US", "UK", "FR", "DE"},
+		},
+		{
+			opts:  []Option{WithOrderFrom("order"), WithOrdering(OrderingDescending), WithResumeAfter("UK")},
+			names: []string{"FR", "DE"},
+		},
+	}
+
+	for i, test := range tests {
+		names, err := Keys(context.Background(), b, test.opts...)
+		assert.Nil(t, err, "test %d", i)
+		assert.Equal(t, test.names, names, "test %d", i)
+	}
+
+	_, err := Keys(context.Background(), b)
+	assert.NotNil(t, err)
+
+	names, err := Keys(context.Background(), b, WithSection("countries"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"DE", "FR", "UK", "US"}, names)
+
+	_, err = Keys(context.Background(), b, WithSection("cities"))
+	assert.NotNil(t, err)
+
+	// The Unpackables' JSON objects are skipped rather than decoded
+	b = []byte(`{ "countries": { "UK": { "capital": 1 }, "FR": [], "UK": "x" } }`)
+
+	names, err = Keys(context.Background(), b, WithOrdering(OrderingDocument))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"UK", "FR"}, names)
+
+	_, err = Keys(context.Background(), b, WithDuplicateNamePolicy(DuplicateNameError))
+	assert.Equal(t, `duplicate name "UK"`, err.Error())
+
+	var le *LimitError
+	_, err = Keys(context.Background(), b, WithMaxItems(1))
+	assert.ErrorAs(t, err, &le)
+}
+
+func TestCount(t *testing.T) {
+
+	type tc struct {
+		json  string
+		opts  []Option
+		count int
+		valid bool
+	}
+
+	tests := []tc{
+		{json: `{ "a": {} }`, count: 0, valid: true},
+		{json: `{ "a": null }`, count: 0, valid: true},
+		{json: `{ "a": { "x": {}, "y": { "z": [1, {"q": []}] }, "z": 1 } }`, count: 3, valid: true},
+		{json: `{ "a": { "x": {}, "x": {} } }`, count: 2, valid: true},
+		{json: `{ "o": ["y"], "a": { "x": {}, "y": {} } }`, opts: []Option{WithOrderFrom("o")}, count: 2, valid: true},
+		{json: `{ "o": ["y"], "a": { "x": {}, "y": {} } }`, valid: false},
+		{json: `{ "a": { "x": {} }, "b": { "x": {}, "y": {} } }`, opts: []Option{WithSection("b")}, count: 2, valid: true},
+		{json: `{ "a": { "x": {} }, "b": { "x": {}, "y": {} } }`, opts: []Option{WithSection("c")}, valid: false},
+		{json: `{ "a": { "x": {} }, "b": { "x": {}, "y": {} } }`, valid: false},
+		{json: `{}`, valid: false},
+		{json: `[]`, valid: false},
+		{json: `{ "a": [] }`, valid: false},
+		{json: `{ "a": { "x": {} }`, valid: false},
+	}
+
+	for i, test := range tests {
+		n, err := Count(context.Background(), []byte(test.json), test.opts...)
+		if !test.valid {
+			assert.NotNil(t, err, "test %d", i)
+			continue
+		}
+		assert.Nil(t, err, "test %d", i)
+		assert.Equal(t, test.count, n, "test %d", i)
+	}
+}
+
+func TestContains(t *testing.T) {
+
+	b := []byte(`
+{
+	"countries": {
+		"UK": { "capital": "London" },
+		"FR": { "capital": "Paris" }
+	}
+}
+	`)
+
+	ctx := context.Background()
+
+	found, err := Contains(ctx, b, "FR")
+	assert.Nil(t, err)
+	assert.True(t, found)
+
+	found, err = Contains(ctx, b, "DE")
+	assert.Nil(t, err)
+	assert.False(t, found)
+
+	found, err = Contains(ctx, b, "capital")
+	assert.Nil(t, err)
+	assert.False(t, found)
+
+	_, err = Contains(ctx, []byte(`{ "countries": { "UK": {} `), "DE")
+	assert.NotNil(t, err)
+}
+
+func TestUnmarshalSection(t *testing.T) {
+
+	b := []byte(`
+{
+	"Time Series (Daily)": {
+		"2023-08-18": { "close": 140.5 },
+		"2023-08-21": { "close": 141.25 }
+	},
+	"Meta Data": { "symbol": "IBM", "refreshed": "2023-08-21" }
+}
+	`)
+
+	ctx := context.Background()
+
+	var meta struct {
+		Symbol    string `json:"symbol"`
+		Refreshed string `json:"refreshed"`
+	}
+	err := UnmarshalSection(ctx, b, "Meta Data", &meta)
+	assert.Nil(t, err)
+	assert.Equal(t, "IBM", meta.Symbol)
+	assert.Equal(t, "2023-08-21", meta.Refreshed)
+
+	u, err := Unpack(b, quotef{}, WithSection("Time Series (Daily)"))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(u))
+
+	err = UnmarshalSection(ctx, b, "Information", &meta)
+	assert.Equal(t, `section "Information" not found`, err.Error())
+
+	err = UnmarshalSection(ctx, b, "Meta Data", &meta, WithMaxBytes(10))
+	assert.NotNil(t, err)
+
+	err = UnmarshalSection(ctx, []byte(`[]`), "Meta Data", &meta)
+	assert.Equal(t, errNotObject, err)
+}
+
+func TestUnmarshalSections(t *testing.T) {
+
+	b := []byte(`
+{
+	"Meta Data": { "symbol": "IBM", "refreshed": "2023-08-21" },
+	"Time Series (Daily)": {
+		"2023-08-18": { "close": 140.5 }
+	},
+	"Rate Limits": { "remaining": 24, "refreshed": "2023-08-22" }
+}
+	`)
+
+	ctx := context.Background()
+
+	type meta struct {
+		Symbol    string `json:"symbol"`
+		Refreshed string `json:"refreshed"`
+		Remaining int    `json:"remaining"`
+	}
+
+	var m meta
+	err := UnmarshalSections(ctx, b, []string{"Meta Data", "Rate Limits"}, &m)
+	assert.Nil(t, err)
+	assert.Equal(t, meta{Symbol: "IBM", Refreshed: "2023-08-22", Remaining: 24}, m)
+
+	// Sections are merged in the order they are named, not the order they appear
+	m = meta{}
+	err = UnmarshalSections(ctx, b, []string{"Rate Limits", "Meta Data"}, &m)
+	assert.Nil(t, err)
+	assert.Equal(t, "2023-08-21", m.Refreshed)
+
+	err = UnmarshalSections(ctx, b, []string{"Meta Data", "Information"}, &m)
+	assert.Equal(t, `section "Information" not found`, err.Error())
+}
+
+func TestUnpackSectionPath(t *testing.T) {
+
+	b := []byte(`
+{
+	"status": "ok",
+	"response": {
+		"page": 1,
+		"data": {
+			"count": 2,
+			"items/daily": {
+				"2023-08-18": { "close": 140.5 },
+				"2023-08-21": { "close": 141.25 }
+			}
+		}
+	}
+}
+	`)
+
+	ctx := context.Background()
+
+	for _, path := range []string{"response.data.items/daily", "/response/data/items~1daily"} {
+		u, err := Unpack(b, quotef{}, WithSectionPath(path))
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(u))
+
+		n, err := Count(ctx, b, WithSectionPath(path))
+		assert.Nil(t, err)
+		assert.Equal(t, 2, n)
+
+		ok, err := Contains(ctx, b, "2023-08-21", WithSectionPath(path))
+		assert.Nil(t, err)
+		assert.True(t, ok)
+	}
+
+	_, err := Unpack(b, quotef{}, WithSectionPath("response.info.items"))
+	assert.Equal(t, `section "info" not found`, err.Error())
+
+	_, err = Count(ctx, b, WithSectionPath("response.info.items"))
+	assert.Equal(t, `section "info" not found`, err.Error())
+
+	_, err = Unpack(b, quotef{}, WithSectionPath("status.items"))
+	assert.NotNil(t, err)
+
+	_, err = Count(ctx, b, WithSectionPath("status.items"))
+	assert.Equal(t, errNotObject, err)
+
+	// WithSection replaces an earlier path
+	_, err = Unpack(b, quotef{}, WithSectionPath("response.data.items/daily"), WithSection("response"))
+	assert.NotNil(t, err)
+}
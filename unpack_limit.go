@@ -0,0 +1,77 @@
+package unpack
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Limiter controls the rate at which Unpackables are populated.
+// *rate.Limiter from golang.org/x/time/rate satisfies this interface.
+type Limiter interface {
+	Wait(ctx context.Context) error
+}
+
+// WithRateLimit waits for the Limiter before populating each Unpackable,
+// so that unpacking very large JSON objects does not starve other work in
+// the process.  The same Limiter can be shared between calls to Unpack, for
+// example via the Options of a Subscriber, to limit their combined rate.
+func WithRateLimit(l Limiter) Option {
+	return func(o *options) {
+		o.limiter = l
+	}
+}
+
+// WithItemsPerSecond is as WithRateLimit, using a Limiter that evenly spaces
+// the population of Unpackables at n per second.  The Limiter is shared by
+// every call to Unpack that uses the returned Option.  If n is not positive,
+// Unpack returns an error rather than populating any Unpackables.
+func WithItemsPerSecond(n float64) Option {
+	l := &intervalLimiter{}
+	if n > 0 {
+		l.interval = time.Duration(float64(time.Second) / n)
+	} else {
+		l.err = fmt.Errorf("invalid rate of %v items per second", n)
+	}
+	return WithRateLimit(l)
+}
+
+// intervalLimiter allows one event per interval
+type intervalLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+	err      error // returned by every Wait, if the rate is invalid
+}
+
+// Wait blocks until the next event is allowed, or the context is done
+func (l *intervalLimiter) Wait(ctx context.Context) error {
+	if l.err != nil {
+		return l.err
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	at := l.next
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	d := time.Until(at)
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
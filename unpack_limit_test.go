@@ -0,0 +1,57 @@
+package unpack
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type countingLimiter struct {
+	waits int
+	err   error
+}
+
+func (l *countingLimiter) Wait(ctx context.Context) error {
+	l.waits++
+	return l.err
+}
+
+func TestUnpackRateLimit(t *testing.T) {
+
+	b := []byte(`{ "a": { "v": {}, "w": {}, "x": {}, "y": {}, "z": {} } }`)
+
+	l := &countingLimiter{}
+	u, err := Unpack(b, ttf{}, WithRateLimit(l), WithLimit(3))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(u))
+	assert.Equal(t, 3, l.waits)
+
+	l.err = errors.New("limited")
+	_, err = Unpack(b, ttf{}, WithRateLimit(l))
+	assert.Equal(t, l.err, err)
+
+	opt := WithItemsPerSecond(200)
+
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		u, err = Unpack(b, ttf{}, opt, WithParallelism(2))
+		assert.Nil(t, err)
+		assert.Equal(t, 5, len(u))
+	}
+	// The limiter is shared, so 10 items are spaced 5ms apart
+	assert.True(t, time.Since(start) >= 45*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err = UnpackContext(ctx, b, ttf{}, WithItemsPerSecond(1))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	for _, n := range []float64{0, -1} {
+		_, err = Unpack(b, ttf{}, WithItemsPerSecond(n))
+		assert.NotNil(t, err)
+	}
+}
@@ -0,0 +1,127 @@
+package unpack
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// LRUView provides point lookups of the Unpackables within a large JSON object,
+// populating each only when it is first requested and retaining at most a
+// fixed number of them, evicting those least recently requested.  Only the
+// JSON and the offsets of each JSON object within it are otherwise retained,
+// so memory use is bounded regardless of the number of Unpackables.
+// It is safe for concurrent use.
+type LRUView[F UnpackableFactory] struct {
+	b        []byte
+	fact     F
+	opts     *options
+	entries  map[string]IndexEntry
+	capacity int
+
+	mu    sync.Mutex
+	order *list.List // of *lruItem, most recently requested first
+	items map[string]*list.Element
+}
+
+// lruItem is a populated Unpackable held by an LRUView
+type lruItem struct {
+	name string
+	u    Unpackable
+}
+
+// NewLRUView returns an LRUView of the JSON, retaining at most capacity
+// populated Unpackables.  The JSON must not be modified while the LRUView is
+// in use.  The options are used both to locate the Unpackables (as BuildIndex)
+// and to populate them.
+func NewLRUView[F UnpackableFactory](ctx context.Context, b []byte, fact F, capacity int, opts ...Option) (*LRUView[F], error) {
+
+	if capacity <= 0 {
+		return nil, errors.New("capacity must be positive")
+	}
+
+	ix, err := BuildIndex(ctx, b, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// As Index.Find, the last entry with a name is used
+	entries := make(map[string]IndexEntry, len(ix))
+	for _, e := range ix {
+		entries[e.Name] = e
+	}
+
+	return &LRUView[F]{
+		b:        b,
+		fact:     fact,
+		opts:     newOptions(opts),
+		entries:  entries,
+		capacity: capacity,
+		order:    list.New(),
+		items:    map[string]*list.Element{},
+	}, nil
+}
+
+// Get returns the Unpackable with the name, populating it if it is not
+// retained.  If the name is not present, an error wrapping ErrNameNotFound
+// is returned.
+func (v *LRUView[F]) Get(name string) (Unpackable, error) {
+
+	v.mu.Lock()
+	if el, ok := v.items[name]; ok {
+		v.order.MoveToFront(el)
+		v.mu.Unlock()
+		return el.Value.(*lruItem).u, nil
+	}
+	v.mu.Unlock()
+
+	e, ok := v.entries[name]
+	if !ok {
+		return nil, fmt.Errorf("%q: %w", name, ErrNameNotFound)
+	}
+
+	u, err := populate(newFunc(v.fact), name, nil, map[string]json.RawMessage{name: e.Bytes(v.b)}, v.opts)
+	if err != nil {
+		return nil, err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	// Another caller may have populated the Unpackable concurrently
+	if el, ok := v.items[name]; ok {
+		v.order.MoveToFront(el)
+		return el.Value.(*lruItem).u, nil
+	}
+
+	v.items[name] = v.order.PushFront(&lruItem{name: name, u: u})
+	if v.order.Len() > v.capacity {
+		oldest := v.order.Back()
+		v.order.Remove(oldest)
+		delete(v.items, oldest.Value.(*lruItem).name)
+	}
+
+	return u, nil
+}
+
+// Contains reports whether an Unpackable with the name is present in the JSON
+func (v *LRUView[F]) Contains(name string) bool {
+	_, ok := v.entries[name]
+	return ok
+}
+
+// Len returns the number of distinct names in the JSON
+func (v *LRUView[F]) Len() int {
+	return len(v.entries)
+}
+
+// Retained returns the number of populated Unpackables currently retained
+func (v *LRUView[F]) Retained() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.order.Len()
+}
@@ -0,0 +1,73 @@
+package unpack
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUView(t *testing.T) {
+
+	var sb strings.Builder
+	sb.WriteString(`{ "history": {`)
+	for i := 0; i < 100; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		fmt.Fprintf(&sb, `"%03d": { "close": %d }`, i, i)
+	}
+	sb.WriteString(`} }`)
+
+	ctx := context.Background()
+
+	v, err := NewLRUView(ctx, []byte(sb.String()), quotef{}, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 100, v.Len())
+	assert.Equal(t, 0, v.Retained())
+	assert.True(t, v.Contains("042"))
+
+	a, err := v.Get("001")
+	assert.Nil(t, err)
+	assert.Equal(t, 1.0, a.(*quote).Close)
+	assert.Equal(t, "001", a.(*quote).name)
+
+	b, _ := v.Get("002")
+	again, _ := v.Get("001")
+	assert.True(t, a == again)
+
+	// "002" is the least recently requested, so is evicted
+	_, _ = v.Get("003")
+	assert.Equal(t, 2, v.Retained())
+	again, _ = v.Get("001")
+	assert.True(t, a == again)
+	again, _ = v.Get("002")
+	assert.False(t, b == again)
+
+	_, err = v.Get("999")
+	assert.ErrorIs(t, err, ErrNameNotFound)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				u, err := v.Get(fmt.Sprintf("%03d", (i*j)%100))
+				assert.Nil(t, err)
+				assert.NotNil(t, u)
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 2, v.Retained())
+
+	_, err = NewLRUView(ctx, []byte(sb.String()), quotef{}, 0)
+	assert.NotNil(t, err)
+
+	_, err = NewLRUView(ctx, []byte(`[]`), quotef{}, 1)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,251 @@
+package unpack
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// FieldSpec describes how an attribute of each JSON object is mapped to a
+// value of a Record
+type FieldSpec struct {
+	Source   string          `json:"source"`   // attribute name within the JSON object
+	Target   string          `json:"target"`   // name of the value within the Record; Source if empty
+	Type     string          `json:"type"`     // "string", "float", "int", "bool" or "time"; if empty, the value as decoded by encoding/json
+	Layout   string          `json:"layout"`   // layout of a "time" value, as used by time.Parse
+	Default  json.RawMessage `json:"default"`  // value used if the attribute is absent or null
+	Required bool            `json:"required"` // whether the attribute must be present, if there is no default
+}
+
+// MappingSpec describes the values of the Records populated from each
+// JSON object, allowing feeds to be unpacked without defining a Go type
+type MappingSpec struct {
+	Fields []FieldSpec `json:"fields"`
+	Strict bool        `json:"strict"` // whether attributes that are not mapped are an error
+}
+
+// mappedField is a compiled FieldSpec
+type mappedField struct {
+	FieldSpec
+	def interface{} // the Default coerced to the Type, if there is one
+}
+
+// Mapping is a compiled MappingSpec.  It is an UnpackableFactory creating
+// Records, so can be passed to Unpack.  Options that affect how the fields
+// of structs are populated, such as WithTagName, WithWeakTyping and
+// WithDecodeHook, do not apply to Records.
+type Mapping struct {
+	fields  []mappedField
+	sources map[string]bool
+	strict  bool
+}
+
+// CompileMapping returns the Mapping for the JSON encoded MappingSpec, such as
+//
+//	{
+//		"fields": [
+//			{ "source": "4. close", "target": "close", "type": "float", "required": true },
+//			{ "source": "5. volume", "target": "volume", "type": "int", "default": 0 }
+//		]
+//	}
+//
+// An error is returned if the spec is incomplete or inconsistent, so that
+// a Mapping can be compiled from configuration when a program starts.
+func CompileMapping(b []byte) (*Mapping, error) {
+
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.DisallowUnknownFields()
+
+	var spec MappingSpec
+	if err := d.Decode(&spec); err != nil {
+		return nil, err
+	}
+	return NewMapping(spec)
+}
+
+// NewMapping returns the Mapping for the MappingSpec, or an error if the spec
+// is incomplete or inconsistent
+func NewMapping(spec MappingSpec) (*Mapping, error) {
+
+	if len(spec.Fields) == 0 {
+		return nil, errors.New("mapping has no fields")
+	}
+
+	m := &Mapping{
+		sources: map[string]bool{},
+		strict:  spec.Strict,
+	}
+
+	targets := map[string]bool{}
+	for _, fs := range spec.Fields {
+		if fs.Source == "" {
+			return nil, errors.New("mapping field has no source")
+		}
+		if fs.Target == "" {
+			fs.Target = fs.Source
+		}
+		if targets[fs.Target] {
+			return nil, fmt.Errorf("mapping target %q is duplicated", fs.Target)
+		}
+		targets[fs.Target] = true
+
+		switch fs.Type {
+		case "", "string", "float", "int", "bool":
+			if fs.Layout != "" {
+				return nil, fmt.Errorf("mapping field %q: layout requires type \"time\"", fs.Source)
+			}
+		case "time":
+			if fs.Layout == "" {
+				return nil, fmt.Errorf("mapping field %q: type \"time\" requires a layout", fs.Source)
+			}
+		default:
+			return nil, fmt.Errorf("mapping field %q: unknown type %q", fs.Source, fs.Type)
+		}
+
+		f := mappedField{FieldSpec: fs}
+		if len(fs.Default) > 0 {
+			var err error
+			if f.def, err = coerce(fs.Default, fs.Type, fs.Layout); err != nil {
+				return nil, fmt.Errorf("mapping field %q: default: %w", fs.Source, err)
+			}
+		}
+
+		m.fields = append(m.fields, f)
+		m.sources[fs.Source] = true
+	}
+
+	return m, nil
+}
+
+// New returns an empty Record populated using the Mapping
+func (m *Mapping) New() Unpackable {
+	return &Record{mapping: m}
+}
+
+// Record is an Unpackable whose values are populated according to a Mapping
+type Record struct {
+	name    string
+	mapping *Mapping
+	values  map[string]interface{}
+}
+
+// SetName assigns the name of the Record
+func (r *Record) SetName(name string) {
+	r.name = name
+}
+
+// Name returns the name of the Record
+func (r *Record) Name() string {
+	return r.name
+}
+
+// Get returns the value with the target name, which is a string, float64,
+// int64, bool or time.Time according to the type of the FieldSpec.  false is
+// returned if the attribute was absent or null and there is no default.
+func (r *Record) Get(target string) (interface{}, bool) {
+	v, ok := r.values[target]
+	return v, ok
+}
+
+// Targets returns the target names of the values held, in ascending order
+func (r *Record) Targets() []string {
+	targets := make([]string, 0, len(r.values))
+	for target := range r.values {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+// UnmarshalJSON populates the Record from the JSON object using its Mapping
+func (r *Record) UnmarshalJSON(b []byte) error {
+
+	if r.mapping == nil {
+		return errors.New("record has no mapping")
+	}
+
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+
+	if r.mapping.strict {
+		unknown := map[string]json.RawMessage{}
+		for k, raw := range m {
+			if !r.mapping.sources[k] {
+				unknown[k] = raw
+			}
+		}
+		if len(unknown) > 0 {
+			return unknownField(unknown)
+		}
+	}
+
+	r.values = make(map[string]interface{}, len(r.mapping.fields))
+	for _, f := range r.mapping.fields {
+		raw, ok := m[f.Source]
+		if !ok || isNull(raw) {
+			switch {
+			case f.def != nil:
+				r.values[f.Target] = f.def
+			case f.Required:
+				return &UnpackError{Path: f.Source, Err: errors.New("required attribute missing")}
+			}
+			continue
+		}
+
+		v, err := coerce(raw, f.Type, f.Layout)
+		if err != nil {
+			return &UnpackError{Path: f.Source, Err: err}
+		}
+		r.values[f.Target] = v
+	}
+
+	return nil
+}
+
+// coerce returns the JSON value as the type named by a FieldSpec, accepting
+// numbers and booleans that are quoted as strings, and numbers and
+// booleans for strings
+func coerce(raw json.RawMessage, typ, layout string) (interface{}, error) {
+
+	var v interface{}
+	if typ == "" {
+		err := json.Unmarshal(raw, &v)
+		return v, err
+	}
+
+	d := json.NewDecoder(bytes.NewReader(raw))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	var s string
+	switch x := v.(type) {
+	case string:
+		s = x
+	case json.Number:
+		s = x.String()
+	case bool:
+		s = strconv.FormatBool(x)
+	default:
+		return nil, fmt.Errorf("cannot convert %s to %s", raw, typ)
+	}
+
+	switch typ {
+	case "float":
+		return strconv.ParseFloat(s, 64)
+	case "int":
+		return strconv.ParseInt(s, 10, 64)
+	case "bool":
+		return strconv.ParseBool(s)
+	case "time":
+		return time.Parse(layout, s)
+	}
+	return s, nil
+}
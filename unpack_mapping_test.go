@@ -0,0 +1,92 @@
+package unpack
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMapping(t *testing.T) {
+
+	m, err := CompileMapping([]byte(`
+{
+	"fields": [
+		{ "source": "4. close", "target": "close", "type": "float", "required": true },
+		{ "source": "5. volume", "target": "volume", "type": "int", "default": "0" },
+		{ "source": "6. halted", "target": "halted", "type": "bool", "default": false },
+		{ "source": "7. settled", "target": "settled", "type": "time", "layout": "2006-01-02" },
+		{ "source": "8. venue", "target": "venue", "type": "string" },
+		{ "source": "9. tags" }
+	]
+}
+	`))
+	assert.Nil(t, err)
+
+	b := []byte(`
+{
+	"history": {
+		"2023-08-18": { "4. close": "140.5", "5. volume": 3000, "7. settled": "2023-08-22", "8. venue": 7, "9. tags": ["a"] },
+		"2023-08-21": { "4. close": 141.25, "5. volume": null, "6. halted": "true", "1. open": 140.75 }
+	}
+}
+	`)
+
+	u, err := Unpack(b, m)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(u))
+
+	r := u[0].(*Record)
+	assert.Equal(t, "2023-08-18", r.Name())
+	assert.Equal(t, []string{"9. tags", "close", "halted", "settled", "venue", "volume"}, r.Targets())
+	v, _ := r.Get("close")
+	assert.Equal(t, 140.5, v)
+	v, _ = r.Get("volume")
+	assert.Equal(t, int64(3000), v)
+	v, _ = r.Get("settled")
+	assert.Equal(t, time.Date(2023, 8, 22, 0, 0, 0, 0, time.UTC), v)
+	v, _ = r.Get("venue")
+	assert.Equal(t, "7", v)
+	v, _ = r.Get("9. tags")
+	assert.Equal(t, []interface{}{"a"}, v)
+
+	r = u[1].(*Record)
+	v, _ = r.Get("volume")
+	assert.Equal(t, int64(0), v)
+	v, _ = r.Get("halted")
+	assert.Equal(t, true, v)
+	_, ok := r.Get("settled")
+	assert.False(t, ok)
+
+	_, err = Unpack([]byte(`{ "history": { "2023-08-18": { "5. volume": 3000 } } }`), m)
+	var ue *UnpackError
+	assert.ErrorAs(t, err, &ue)
+	assert.Equal(t, "4. close", ue.Path)
+
+	_, err = Unpack([]byte(`{ "history": { "2023-08-18": { "4. close": "n/a" } } }`), m)
+	assert.ErrorAs(t, err, &ue)
+	assert.Equal(t, "4. close", ue.Path)
+
+	strict, err := NewMapping(MappingSpec{Fields: []FieldSpec{{Source: "4. close", Type: "float"}}, Strict: true})
+	assert.Nil(t, err)
+	_, err = Unpack([]byte(`{ "history": { "2023-08-18": { "4. close": 140.5, "1. open": 140.75 } } }`), strict)
+	assert.ErrorAs(t, err, &ue)
+	assert.Equal(t, "1. open", ue.Path)
+}
+
+func TestCompileMappingErrors(t *testing.T) {
+
+	for _, spec := range []string{
+		`{ "fields": [] }`,
+		`{ "fields": [ { "target": "close" } ] }`,
+		`{ "fields": [ { "source": "a", "target": "x" }, { "source": "b", "target": "x" } ] }`,
+		`{ "fields": [ { "source": "a", "type": "decimal" } ] }`,
+		`{ "fields": [ { "source": "a", "type": "time" } ] }`,
+		`{ "fields": [ { "source": "a", "type": "float", "layout": "2006" } ] }`,
+		`{ "fields": [ { "source": "a", "type": "int", "default": "none" } ] }`,
+		`{ "fields": [ { "source": "a", "coerce": "int" } ] }`,
+	} {
+		_, err := CompileMapping([]byte(spec))
+		assert.NotNil(t, err, spec)
+	}
+}
@@ -0,0 +1,121 @@
+package unpack
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+)
+
+// Envelope removes the framing that a messaging system (such as Kafka or a queue)
+// has added to a message value, returning the JSON object it contains
+type Envelope interface {
+	Open(value []byte, headers map[string]string) ([]byte, error)
+}
+
+// EnvelopeFunc allows a function to be used as an Envelope
+type EnvelopeFunc func(value []byte, headers map[string]string) ([]byte, error)
+
+// Open calls f
+func (f EnvelopeFunc) Open(value []byte, headers map[string]string) ([]byte, error) {
+	return f(value, headers)
+}
+
+// Envelopes opens each of its Envelopes in turn, outermost first
+type Envelopes []Envelope
+
+// Open opens each Envelope in turn, passing the result of each to the next
+func (e Envelopes) Open(value []byte, headers map[string]string) ([]byte, error) {
+	var err error
+	for _, env := range e {
+		if value, err = env.Open(value, headers); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+// ErrNoSchemaRegistryHeader is returned by SchemaRegistryEnvelope when
+// the message value does not start with the schema registry header
+var ErrNoSchemaRegistryHeader = errors.New("value does not have a schema registry header")
+
+// SchemaRegistryEnvelope removes the schema registry wire format header
+// (a zero magic byte followed by a 4 byte schema ID) from a message value
+type SchemaRegistryEnvelope struct{}
+
+// Open removes the schema registry header
+func (SchemaRegistryEnvelope) Open(value []byte, headers map[string]string) ([]byte, error) {
+	if len(value) < 5 || value[0] != 0 {
+		return nil, ErrNoSchemaRegistryHeader
+	}
+	return value[5:], nil
+}
+
+// DefaultMaxDecompressedBytes is the largest value that GzipEnvelope
+// decompresses to, unless its MaxBytes is set
+const DefaultMaxDecompressedBytes = 64 << 20
+
+// GzipEnvelope decompresses gzip compressed message values.  If Header is set,
+// values are only decompressed when that header has the value "gzip";
+// otherwise values are decompressed when they start with the gzip magic bytes.
+// A value that decompresses to more than MaxBytes (DefaultMaxDecompressedBytes
+// if not positive) is rejected with a *LimitError, protecting against
+// decompression bombs, which WithMaxBytes cannot detect until too late.
+type GzipEnvelope struct {
+	Header   string
+	MaxBytes int
+}
+
+// Open decompresses the message value if it is compressed
+func (g GzipEnvelope) Open(value []byte, headers map[string]string) ([]byte, error) {
+	if g.Header != "" {
+		if headers[g.Header] != "gzip" {
+			return value, nil
+		}
+	} else if len(value) < 2 || value[0] != 0x1f || value[1] != 0x8b {
+		return value, nil
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(value))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	limit := g.MaxBytes
+	if limit <= 0 {
+		limit = DefaultMaxDecompressedBytes
+	}
+
+	b, err := io.ReadAll(io.LimitReader(r, int64(limit)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > limit {
+		// Decompression stops once the limit is exceeded, so the actual size is unknown
+		return nil, &LimitError{Limit: "decompressed bytes", Max: limit, Actual: len(b)}
+	}
+	return b, nil
+}
+
+// MessageDecoder unpacks the values of messages consumed from a messaging system,
+// so that event pipeline consumers can handle name keyed message bodies uniformly
+type MessageDecoder[F UnpackableFactory] struct {
+	// Envelope, if set, is opened before the value is unpacked
+	Envelope Envelope
+	// Factory creates the Unpackables
+	Factory F
+	// Options are applied when unpacking each value
+	Options []Option
+}
+
+// DecodeMessage opens the message value's Envelope and unpacks the JSON object it contains
+func (m *MessageDecoder[F]) DecodeMessage(value []byte, headers map[string]string) ([]Unpackable, error) {
+	if m.Envelope != nil {
+		var err error
+		if value, err = m.Envelope.Open(value, headers); err != nil {
+			return nil, err
+		}
+	}
+	return Unpack(value, m.Factory, m.Options...)
+}
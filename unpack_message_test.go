@@ -0,0 +1,76 @@
+package unpack
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageDecoder(t *testing.T) {
+
+	payload := []byte(`{ "quotes": { "IBM": { "close": 140.5 }, "AAPL": { "close": 175.1 } } }`)
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write(payload)
+	w.Close()
+	compressed := buf.Bytes()
+
+	registry := func(b []byte) []byte {
+		return append([]byte{0, 0, 0, 0, 42}, b...)
+	}
+
+	type tc struct {
+		env     Envelope
+		value   []byte
+		headers map[string]string
+		valid   bool
+	}
+
+	tests := []tc{
+		{env: nil, value: payload, valid: true},
+		{env: SchemaRegistryEnvelope{}, value: registry(payload), valid: true},
+		{env: SchemaRegistryEnvelope{}, value: payload, valid: false},
+		{env: GzipEnvelope{}, value: compressed, valid: true},
+		{env: GzipEnvelope{}, value: payload, valid: true},
+		{env: GzipEnvelope{Header: "encoding"}, value: compressed, headers: map[string]string{"encoding": "gzip"}, valid: true},
+		{env: GzipEnvelope{Header: "encoding"}, value: compressed, valid: false},
+		{env: GzipEnvelope{MaxBytes: len(payload)}, value: compressed, valid: true},
+		{env: GzipEnvelope{MaxBytes: len(payload) - 1}, value: compressed, valid: false},
+		{env: Envelopes{SchemaRegistryEnvelope{}, GzipEnvelope{}}, value: registry(compressed), valid: true},
+		{env: Envelopes{GzipEnvelope{}, SchemaRegistryEnvelope{}}, value: registry(compressed), valid: false},
+		{env: EnvelopeFunc(func(value []byte, headers map[string]string) ([]byte, error) {
+			return value[1:], nil
+		}), value: append([]byte("x"), payload...), valid: true},
+	}
+
+	for i, test := range tests {
+		m := &MessageDecoder[quotef]{
+			Envelope: test.env,
+			Factory:  quotef{},
+		}
+
+		u, err := m.DecodeMessage(test.value, test.headers)
+		if !test.valid {
+			assert.NotNil(t, err, "test %d", i)
+			continue
+		}
+		assert.Nil(t, err, "test %d", i)
+		assert.Equal(t, 2, len(u), "test %d", i)
+		assert.Equal(t, "AAPL", u[0].(*quote).name, "test %d", i)
+	}
+
+	// Decompression stops at the limit
+	var bomb bytes.Buffer
+	w = gzip.NewWriter(&bomb)
+	w.Write(make([]byte, 1<<20))
+	w.Close()
+
+	_, err := GzipEnvelope{MaxBytes: 1024}.Open(bomb.Bytes(), nil)
+	var le *LimitError
+	assert.ErrorAs(t, err, &le)
+	assert.Equal(t, "decompressed bytes", le.Limit)
+	assert.Equal(t, 1024, le.Max)
+}
@@ -0,0 +1,194 @@
+package unpack
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DuplicateNamePolicy determines how a name that appears more than
+// once within the JSON object is handled
+type DuplicateNamePolicy int
+
+const (
+	// DuplicateNameLast uses the last JSON object with the name (the default,
+	// consistent with encoding/json)
+	DuplicateNameLast DuplicateNamePolicy = iota
+	// DuplicateNameFirst uses the first JSON object with the name
+	DuplicateNameFirst
+	// DuplicateNameError returns an error if any name is duplicated
+	DuplicateNameError
+)
+
+// scanItems returns the JSON objects by name, applying the duplicate name policy,
+// together with the names in the order they first appear in the JSON object
+func scanItems(b []byte, policy DuplicateNamePolicy) (map[string]json.RawMessage, []string, error) {
+
+	var (
+		items = map[string]json.RawMessage{}
+		names []string
+	)
+
+	err := scanObject(b, func(name string, value json.RawMessage) error {
+		if _, ok := items[name]; ok {
+			switch policy {
+			case DuplicateNameError:
+				return fmt.Errorf("duplicate name %q", name)
+			case DuplicateNameFirst:
+				return nil
+			}
+		} else {
+			names = append(names, name)
+		}
+		items[name] = value
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return items, names, nil
+}
+
+// UnknownSectionPolicy determines how top level attributes of the JSON
+// object other than that named by WithSection are handled, once those named
+// by WithOrderFrom and WithSkipSections, and any handled by
+// WithSectionHandler, are excluded
+type UnknownSectionPolicy int
+
+const (
+	// UnknownSectionIgnore ignores them (the default)
+	UnknownSectionIgnore UnknownSectionPolicy = iota
+	// UnknownSectionWarn returns the Unpackables together with an
+	// *UnknownSectionsError naming them (within an Errors if there are
+	// other errors)
+	UnknownSectionWarn
+	// UnknownSectionError returns an *UnknownSectionsError naming them
+	UnknownSectionError
+)
+
+// NameCollisionPolicy determines how names that differ only in
+// surrounding whitespace or letter case (such as "UK " and "uk") are handled
+type NameCollisionPolicy int
+
+const (
+	// NameCollisionKeep treats colliding names as distinct (the default)
+	NameCollisionKeep NameCollisionPolicy = iota
+	// NameCollisionError returns an error if any names collide
+	NameCollisionError
+	// NameCollisionMerge populates a single Unpackable from all the colliding
+	// JSON objects, in the order the names would otherwise be returned, so that
+	// later attributes replace earlier ones.  It is named using the first name.
+	NameCollisionMerge
+)
+
+// normalizeName returns the form of the name used to detect collisions,
+// unless the names are transformed (see WithNameTransform)
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// NameNormalization specifies how names are canonicalised before being set
+// on the Unpackables; values can be combined, for example
+// NameTrimSpace|NameLowerCase
+type NameNormalization int
+
+const (
+	// NameTrimSpace removes leading and trailing white space
+	NameTrimSpace NameNormalization = 1 << iota
+	// NameLowerCase converts names to lower case
+	NameLowerCase
+)
+
+// transformName returns the name to be set on the Unpackable, after
+// applying any normalization and then any transform
+func (o *options) transformName(name string) string {
+	if o.nameNormalization&NameTrimSpace != 0 {
+		name = strings.TrimSpace(name)
+	}
+	if o.nameNormalization&NameLowerCase != 0 {
+		name = strings.ToLower(name)
+	}
+	if o.nameTransform != nil {
+		name = o.nameTransform(name)
+	}
+	return name
+}
+
+// OriginalNamed is implemented by Unpackables that retain the name as it
+// appears in the JSON, when a different name is passed to SetName as a result
+// of WithNameNormalization, WithNameTransform or WithKeyTimeZone.
+// SetOriginalName is called after SetName.
+type OriginalNamed interface {
+	SetOriginalName(name string)
+}
+
+// convertZone returns the name, which is a time, re-expressed in the
+// location specified by WithKeyTimeZone
+func (o *options) convertZone(name string) (string, error) {
+	from, to := o.keyFrom, o.keyTo
+	if from == nil {
+		from = time.UTC
+	}
+	if to == nil {
+		to = time.UTC
+	}
+
+	t, err := time.ParseInLocation(o.keyLayout, name, from)
+	if err != nil {
+		return "", fmt.Errorf("name %q is not a time: %w", name, err)
+	}
+	return t.In(to).Format(o.keyLayout), nil
+}
+
+// collisionKey returns the function giving the form of a name that is
+// compared to detect collisions
+func (o *options) collisionKey() func(name string) string {
+	if o.nameNormalization != 0 || o.nameTransform != nil {
+		return o.transformName
+	}
+	return normalizeName
+}
+
+// collideNames applies the policy to the names, which collide if they have
+// the same key, returning the names to be unpacked and, for NameCollisionMerge,
+// the names merged into each of them
+func collideNames(names []string, policy NameCollisionPolicy, key func(name string) string) ([]string, map[string][]string, error) {
+
+	if policy == NameCollisionKeep {
+		return names, nil, nil
+	}
+
+	var (
+		seen   = make(map[string]string, len(names))
+		kept   = make([]string, 0, len(names))
+		merged = map[string][]string{}
+	)
+
+	for _, name := range names {
+		k := key(name)
+		if first, ok := seen[k]; ok {
+			if policy == NameCollisionError {
+				return nil, nil, fmt.Errorf("names %q and %q collide", first, name)
+			}
+			merged[first] = append(merged[first], name)
+			continue
+		}
+		seen[k] = name
+		kept = append(kept, name)
+	}
+
+	return kept, merged, nil
+}
+
+// filterNames returns the names for which fn returns true, retaining their sequence
+func filterNames(names []string, fn func(name string) bool) []string {
+	ret := names[:0]
+	for _, name := range names {
+		if fn(name) {
+			ret = append(ret, name)
+		}
+	}
+	return ret
+}
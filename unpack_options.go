@@ -0,0 +1,647 @@
+package unpack
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Option modifies the default behaviour of Unpack
+type Option func(*options)
+
+type options struct {
+	timeLayouts []string
+	orderFrom   string
+	ordering    Ordering
+	less        func(a, b string) bool
+	sortBy      func(a, b Unpackable) bool
+	anomalyFn   func(prev, curr Unpackable) error
+	collator    Collator
+	codec       Codec
+	section     string
+	sectionPath []string
+	skipped     []string
+	handlers    []sectionHandler
+
+	unknownSections UnknownSectionPolicy
+	extras          func(extras map[string]json.RawMessage)
+	errorSections   []string
+
+	timeLayout   string
+	timeLocation *time.Location
+
+	checkpointEvery int
+	checkpointFn    func(name string) error
+	resumeAfter     string
+
+	parallelism int
+	auto        bool
+
+	progressFn func(done, total int)
+	limiter    Limiter
+
+	maxItems int
+	maxBytes int
+	maxDepth int
+
+	maxDecodeDuration time.Duration
+
+	maxValueLength    int
+	fieldValueLengths map[string]int
+	truncateValues    bool
+
+	interner *interner
+
+	initFn func(name string, u Unpackable) error
+
+	strictFields    bool
+	useNumber       bool
+	weakTyping      bool
+	decodeHooks     []DecodeHook
+	caseSensitive   bool
+	tagName         string
+	discriminator   string
+	continueOnError bool
+	tombstones      bool
+	tombstoneFn     func(name string)
+	errorHandler    func(name string, raw json.RawMessage, err error) error
+
+	selectFn    func(names []string) []string
+	filterFn    func(name string) bool
+	namePattern *regexp.Regexp
+
+	limit  int
+	offset int
+
+	keys            []string
+	skipMissingKeys bool
+
+	nameCollisions NameCollisionPolicy
+	duplicateNames DuplicateNamePolicy
+
+	nameTransform     func(name string) string
+	nameNormalization NameNormalization
+
+	keyLayout string
+	keyFrom   *time.Location
+	keyTo     *time.Location
+
+	recorder *Recorder
+
+	decoded map[string]json.RawMessage // the top level attributes, if already decoded by the caller
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		codec:         StdCodec{},
+		discriminator: defaultDiscriminator,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithTimeLayouts provides the layouts (see time.Parse) that are tried, in order,
+// when populating time.Time and *time.Time attributes from JSON strings.
+// A `layout` tag on an attribute takes precedence over these layouts.
+func WithTimeLayouts(layouts ...string) Option {
+	return func(o *options) {
+		o.timeLayouts = append(o.timeLayouts, layouts...)
+	}
+}
+
+// WithOrdering specifies the order in which the Unpackables are returned
+func WithOrdering(ordering Ordering) Option {
+	return func(o *options) {
+		o.ordering = ordering
+	}
+}
+
+// WithLess orders the Unpackables using less to compare their names, in place
+// of WithOrdering, allowing orderings such as by version or ISO week.
+// Names that less reports as equal retain the order given by WithOrdering.
+func WithLess(less func(a, b string) bool) Option {
+	return func(o *options) {
+		o.less = less
+	}
+}
+
+// WithCollator compares names using the Collator, such as a *collate.Collator,
+// so that names with accented or non-Latin characters are ordered according
+// to the rules of a locale rather than by their bytes.  The order is ascending
+// unless WithOrdering specifies OrderingDescending.  As *collate.Collator is
+// not safe for concurrent use, it should not be shared by concurrent calls.
+func WithCollator(c Collator) Option {
+	return func(o *options) {
+		o.collator = c
+	}
+}
+
+// WithSortBy orders the Unpackables after they have been populated, using
+// less to compare them, so that they can be ordered by an attribute rather
+// than by name.  Unpackables that less reports as equal retain the order of
+// their names.  The Unpackables are expected to all be of type T.
+func WithSortBy[T Unpackable](less func(a, b T) bool) Option {
+	return func(o *options) {
+		o.sortBy = func(a, b Unpackable) bool {
+			ta, ok := a.(T)
+			if !ok {
+				return false
+			}
+			tb, ok := b.(T)
+			if !ok {
+				return false
+			}
+			return less(ta, tb)
+		}
+	}
+}
+
+// WithAnomalyDetector calls detect for each pair of consecutive Unpackables,
+// in the order they are returned, to flag suspicious changes such as a large
+// gap between closing prices.  The errors that detect returns do not prevent
+// the Unpackables being returned; they are reported as an Anomalies, together
+// with all the Unpackables.  The Unpackables are expected to all be of type T.
+func WithAnomalyDetector[T Unpackable](detect func(prev, curr T) error) Option {
+	return func(o *options) {
+		o.anomalyFn = func(prev, curr Unpackable) error {
+			tp, ok := prev.(T)
+			if !ok {
+				return nil
+			}
+			tc, ok := curr.(T)
+			if !ok {
+				return nil
+			}
+			return detect(tp, tc)
+		}
+	}
+}
+
+// WithTimeOrdering orders the Unpackables by the times their names represent
+// when parsed using the layout (see time.ParseInLocation) in the location,
+// which defaults to UTC if nil.  The order is ascending unless WithOrdering
+// specifies OrderingDescending.  An error is returned if any name cannot be
+// parsed.  This is needed for layouts such as "02-01-2006", which do not order
+// correctly as strings.
+func WithTimeOrdering(layout string, loc *time.Location) Option {
+	return func(o *options) {
+		o.timeLayout = layout
+		o.timeLocation = loc
+	}
+}
+
+// WithOrderFrom specifies a second top level attribute of the JSON object, whose
+// value is an array of names that defines the order of the returned Unpackables.
+// Unpackables whose names are not in the array are returned after those that are,
+// ordered as specified by WithOrdering.
+func WithOrderFrom(name string) Option {
+	return func(o *options) {
+		o.orderFrom = name
+	}
+}
+
+// WithSection specifies the top level attribute of the JSON object whose value
+// holds the Unpackables, so that other attributes are ignored rather than
+// causing the JSON to be treated as incorrectly formed.
+func WithSection(name string) Option {
+	return func(o *options) {
+		o.section = name
+		o.sectionPath = nil
+	}
+}
+
+// WithSectionPath is as WithSection, but for JSON in which the Unpackables
+// are nested a few levels deep.  The path is either a JSON Pointer (such as
+// "/response/data/items") or the attribute names separated by '.' (such as
+// "response.data.items").  The options naming other top level attributes,
+// such as WithSkipSections, continue to apply to the outermost JSON object.
+func WithSectionPath(path string) Option {
+	var names []string
+	if strings.HasPrefix(path, "/") {
+		names = parsePointer(path)
+	} else {
+		names = strings.Split(path, ".")
+	}
+
+	return func(o *options) {
+		o.section = names[0]
+		o.sectionPath = names[1:]
+	}
+}
+
+// WithSkipSections specifies top level attributes of the JSON object that
+// are ignored, such as metadata alongside the Unpackables
+func WithSkipSections(names ...string) Option {
+	return func(o *options) {
+		o.skipped = append(o.skipped, names...)
+	}
+}
+
+// sectionHandler is a handler of top level attributes, added by WithSectionHandler
+type sectionHandler struct {
+	pattern *regexp.Regexp
+	fn      func(name string, raw json.RawMessage) error
+}
+
+// WithSectionHandler calls fn with the name and value of each top level
+// attribute of the JSON object whose name matches the pattern, and that does
+// not hold the Unpackables and is not named by WithOrderFrom or
+// WithSkipSections, so that unexpected sections such as notes, warnings or
+// pagination can be handled rather than causing the JSON to be treated as
+// incorrectly formed.  The handled attributes are then ignored.  Handlers
+// are called in ascending order of attribute name before any Unpackable is
+// populated; if several patterns match, the handler added first is used.
+// If fn returns an error, it is returned.
+func WithSectionHandler(pattern *regexp.Regexp, fn func(name string, raw json.RawMessage) error) Option {
+	return func(o *options) {
+		o.handlers = append(o.handlers, sectionHandler{pattern: pattern, fn: fn})
+	}
+}
+
+// WithUnknownSections specifies how top level attributes of the JSON object
+// are handled when WithSection is used and they are not otherwise expected,
+// so that sections such as provider error messages can be noticed.
+// Without WithSection, any such attribute causes the JSON to be treated as
+// incorrectly formed.
+func WithUnknownSections(policy UnknownSectionPolicy) Option {
+	return func(o *options) {
+		o.unknownSections = policy
+	}
+}
+
+// WithCaptureExtras calls fn, when WithSection is used, with the top level
+// attributes of the JSON object other than that holding the Unpackables,
+// excluding those named by WithOrderFrom and WithSkipSections and any
+// handled by WithSectionHandler, so that additions to the JSON can be
+// observed rather than discarded.  The map is empty if there are none.
+// fn is called once by each call that unpacks a JSON object, so may be
+// called concurrently if the options are shared, as by a Subscriber with
+// several workers.
+func WithCaptureExtras(fn func(extras map[string]json.RawMessage)) Option {
+	return func(o *options) {
+		o.extras = fn
+	}
+}
+
+// WithErrorSections names top level attributes, such as "Error Message" or
+// "Note", that providers include in place of data to report a failure (often
+// with a successful HTTP status).  If any is present, a *ProviderError
+// carrying its message is returned rather than a less helpful error about
+// the form of the JSON.  The names are checked in the order given.
+func WithErrorSections(names ...string) Option {
+	return func(o *options) {
+		o.errorSections = append(o.errorSections, names...)
+	}
+}
+
+// WithCodec replaces encoding/json with the specified Codec
+func WithCodec(codec Codec) Option {
+	return func(o *options) {
+		o.codec = codec
+	}
+}
+
+// WithCheckpoint calls fn with the name of the last Unpackable to be populated,
+// after every n Unpackables and after the final Unpackable.
+// An error returned by fn stops Unpack, which returns the error.
+// Combined with WithResumeAfter, this allows an interrupted job to restart
+// without processing the Unpackables that had already completed.
+func WithCheckpoint(n int, fn func(name string) error) Option {
+	return func(o *options) {
+		if n < 1 {
+			n = 1
+		}
+		o.checkpointEvery = n
+		o.checkpointFn = fn
+	}
+}
+
+// WithResumeAfter skips all Unpackables up to and including the one with the
+// specified name, in the order that the Unpackables would otherwise be returned.
+// It is an error if the name is not present.
+func WithResumeAfter(name string) Option {
+	return func(o *options) {
+		o.resumeAfter = name
+	}
+}
+
+// WithParallelism populates the Unpackables concurrently, using n goroutines.
+// The order of the returned Unpackables is unchanged, and if any Unpackable
+// cannot be populated, the error returned is the one sequential processing
+// would have returned.  The UnpackableFactory, and Codec if provided, must
+// be safe for concurrent use.
+func WithParallelism(n int) Option {
+	return func(o *options) {
+		o.parallelism = n
+	}
+}
+
+// WithProgress calls fn after each Unpackable is populated, with the number
+// populated so far and the total to be populated.  Calls are never concurrent,
+// even when WithParallelism is used.
+func WithProgress(fn func(done, total int)) Option {
+	return func(o *options) {
+		o.progressFn = fn
+	}
+}
+
+// WithMaxItems limits the number of Unpackables that the JSON may contain.
+// If the limit is exceeded, no Unpackables are populated and a *LimitError is returned.
+// The Unpackables are counted by scanning the tokens of the JSON object before
+// it is decoded, stopping once the limit is exceeded, so the Actual of the
+// *LimitError is one more than the limit.
+func WithMaxItems(n int) Option {
+	return func(o *options) {
+		o.maxItems = n
+	}
+}
+
+// WithMaxBytes limits the size of the JSON, which is checked before it is parsed.
+// If the limit is exceeded, a *LimitError is returned.
+func WithMaxBytes(n int) Option {
+	return func(o *options) {
+		o.maxBytes = n
+	}
+}
+
+// WithMaxDepth limits the nesting of objects and arrays within each Unpackable's
+// JSON object, which is checked before it is parsed.  The JSON object itself
+// has a depth of 1.  If the limit is exceeded, a *LimitError is returned.
+func WithMaxDepth(n int) Option {
+	return func(o *options) {
+		o.maxDepth = n
+	}
+}
+
+// WithMaxDecodeDuration returns ErrDecodeDurationExceeded if the Unpackables
+// have not all been populated within the duration, which is checked before
+// each Unpackable is populated.  If WithContinueOnError is also used, those
+// populated before the first that could not be are returned, together with an
+// Errors that includes ErrDecodeDurationExceeded.
+func WithMaxDecodeDuration(d time.Duration) Option {
+	return func(o *options) {
+		o.maxDecodeDuration = d
+	}
+}
+
+// WithMaxValueLength limits the length of string values within each
+// Unpackable's JSON object to n bytes, as encoded in the JSON.  If a string
+// is longer, a *LimitError is returned unless WithTruncateValues is used.
+func WithMaxValueLength(n int) Option {
+	return func(o *options) {
+		o.maxValueLength = n
+	}
+}
+
+// WithFieldMaxValueLength overrides WithMaxValueLength for the strings within
+// the top level attribute of each Unpackable's JSON object; 0 removes the limit
+func WithFieldMaxValueLength(attribute string, n int) Option {
+	return func(o *options) {
+		if o.fieldValueLengths == nil {
+			o.fieldValueLengths = map[string]int{}
+		}
+		o.fieldValueLengths[attribute] = n
+	}
+}
+
+// WithTruncateValues truncates strings that exceed the limits of
+// WithMaxValueLength and WithFieldMaxValueLength, rather than returning an error
+func WithTruncateValues() Option {
+	return func(o *options) {
+		o.truncateValues = true
+	}
+}
+
+// WithStringInterning replaces each string value of the populated Unpackables
+// with a single shared copy of that value, reducing the memory retained when
+// values (such as "0.0000") are repeated across many Unpackables.  Only
+// exported fields, and the maps, slices and pointers they hold, are interned.
+func WithStringInterning() Option {
+	return func(o *options) {
+		o.interner = newInterner()
+	}
+}
+
+// WithTombstones treats JSON objects that are null, or whose Unpackable has
+// a bool field tagged `unpack:",deleted"` that is true, as tombstones marking
+// names that have been deleted, so that incremental feeds can communicate
+// removals.  Tombstones are not returned as Unpackables; instead fn, if not
+// nil, is called with the name of each, in the order they would otherwise
+// have been returned, before Unpack returns.
+func WithTombstones(fn func(name string)) Option {
+	return func(o *options) {
+		o.tombstones = true
+		o.tombstoneFn = fn
+	}
+}
+
+// WithInitFn calls fn for each Unpackable after it has been populated and
+// named, allowing derived attributes to be calculated.
+// An error returned by fn stops Unpack, which returns the error.
+func WithInitFn(fn func(name string, u Unpackable) error) Option {
+	return func(o *options) {
+		o.initFn = fn
+	}
+}
+
+// WithStrictFields returns an error, naming the Unpackable and the attribute,
+// if a JSON object has an attribute that does not map to a field of its
+// Unpackable.  By default such attributes are ignored.
+// If WithCodec is used, its Decoder must have a DisallowUnknownFields method.
+func WithStrictFields() Option {
+	return func(o *options) {
+		o.strictFields = true
+	}
+}
+
+// WithUseNumber decodes numbers into interface{} values, including those of
+// the field receiving unmatched attributes, as json.Number rather than float64,
+// so that large integers do not lose precision.
+// If WithCodec is used, its Decoder must have a UseNumber method.
+func WithUseNumber() Option {
+	return func(o *options) {
+		o.useNumber = true
+	}
+}
+
+// WithWeakTyping converts attribute values to the types of the top level
+// fields they populate where encoding/json would otherwise fail: for example
+// "123" populates an int, 1 a string and "true" a bool.  This allows feeds
+// that deliver all values as strings to populate properly typed structs.
+// Values that cannot be converted still cause an error.
+func WithWeakTyping() Option {
+	return func(o *options) {
+		o.weakTyping = true
+	}
+}
+
+// WithDiscriminator specifies the attribute of the JSON object of a field of
+// an interface type whose value selects the type, registered using
+// RegisterInterfaceImpl, that the field is populated with.  The default is "type".
+func WithDiscriminator(attr string) Option {
+	return func(o *options) {
+		o.discriminator = attr
+	}
+}
+
+// WithDecodeHook adds a hook that converts the value of each attribute that
+// populates a top level field before it is decoded, so that conversions not
+// provided by encoding/json (such as a string to a time.Duration or a
+// decimal) can be plugged in.  Hooks are applied in the order they are added.
+func WithDecodeHook(hook DecodeHook) Option {
+	return func(o *options) {
+		o.decodeHooks = append(o.decodeHooks, hook)
+	}
+}
+
+// WithTagName takes the attribute names of the top level fields of each
+// Unpackable from the tag (such as "mapstructure"), rather than from the json
+// tag, so that structs already tagged for other decoders can be reused.
+// Fields without the tag use their field name, and fields whose json tag is
+// "-" are not populated.  Nested structs continue to use their json tags.
+func WithTagName(tag string) Option {
+	return func(o *options) {
+		if tag == "json" {
+			tag = ""
+		}
+		o.tagName = tag
+	}
+}
+
+// WithCaseSensitiveFields requires the attribute names of each Unpackable's
+// JSON object to match the names of its fields exactly, rather than ignoring
+// differences of case as encoding/json does.  Attributes that only match when
+// case is ignored are treated as unmatched.  This applies to the top level
+// fields of the Unpackable.
+func WithCaseSensitiveFields() Option {
+	return func(o *options) {
+		o.caseSensitive = true
+	}
+}
+
+// WithNameCollisionPolicy specifies how names that differ only in surrounding
+// whitespace or letter case are handled.  By default they are treated as distinct.
+// If WithNameTransform or WithNameNormalization is used, names collide when
+// they are the same once transformed.
+func WithNameCollisionPolicy(policy NameCollisionPolicy) Option {
+	return func(o *options) {
+		o.nameCollisions = policy
+	}
+}
+
+// WithNameTransform applies fn to each name before it is passed to SetName,
+// NewNamed and the function of WithInitFn, so that inconsistent names are
+// canonicalised (for example norm.NFC.String from golang.org/x/text/unicode/norm).
+// It is applied after WithNameNormalization.  Errors, checkpoints and the
+// options selecting names use the names as they appear in the JSON.
+func WithNameTransform(fn func(name string) string) Option {
+	return func(o *options) {
+		o.nameTransform = fn
+	}
+}
+
+// WithNameNormalization is as WithNameTransform, using the normalization
+func WithNameNormalization(n NameNormalization) Option {
+	return func(o *options) {
+		o.nameNormalization = n
+	}
+}
+
+// WithKeyTimeZone re-expresses names that are times, parsed using the layout
+// (see time.ParseInLocation) in the location from, as the same instants in
+// the location to, formatted using the layout; for example to convert names
+// recorded in "America/New_York" to UTC.  Either location defaults to UTC if
+// nil.  The converted names are passed to SetName, NewNamed and the function
+// of WithInitFn, after WithNameNormalization and WithNameTransform are
+// applied.  An Unpackable that implements OriginalNamed also receives the name
+// as it appears in the JSON.  An error is returned if a name cannot be parsed.
+func WithKeyTimeZone(layout string, from, to *time.Location) Option {
+	return func(o *options) {
+		o.keyLayout = layout
+		o.keyFrom = from
+		o.keyTo = to
+	}
+}
+
+// WithDuplicateNamePolicy specifies how a name that appears more than once is
+// handled.  By default the last JSON object with the name is used, as with
+// encoding/json; other policies require the JSON to be scanned token by token.
+func WithDuplicateNamePolicy(policy DuplicateNamePolicy) Option {
+	return func(o *options) {
+		o.duplicateNames = policy
+	}
+}
+
+// WithContinueOnError continues after an Unpackable cannot be populated, rather
+// than stopping.  The Unpackables that were successfully populated are returned,
+// together with an Errors describing each that was not.
+func WithContinueOnError() Option {
+	return func(o *options) {
+		o.continueOnError = true
+	}
+}
+
+// WithErrorHandler calls fn when an Unpackable cannot be populated, with its
+// name, its JSON object and the error, allowing the failure to be logged.
+// If fn returns nil, the Unpackable is omitted and unpacking continues;
+// otherwise the error returned is treated as the Unpackable's error.
+// When WithParallelism is used, fn must be safe for concurrent use.
+func WithErrorHandler(fn func(name string, raw json.RawMessage, err error) error) Option {
+	return func(o *options) {
+		o.errorHandler = fn
+	}
+}
+
+// WithFilter restricts the Unpackables to those whose names fn returns true for.
+// fn is called before any Unpackable is populated, so that the cost of
+// decoding is only incurred for the Unpackables that are required.
+func WithFilter(fn func(name string) bool) Option {
+	return func(o *options) {
+		o.filterFn = fn
+	}
+}
+
+// WithKeys restricts the Unpackables to those with the specified names.
+// If any of the names is not present, an error wrapping ErrNameNotFound
+// is returned, unless WithSkipMissingKeys is also used.
+func WithKeys(names ...string) Option {
+	return func(o *options) {
+		o.keys = append(o.keys, names...)
+	}
+}
+
+// WithSkipMissingKeys ignores names specified by WithKeys that are not present
+func WithSkipMissingKeys() Option {
+	return func(o *options) {
+		o.skipMissingKeys = true
+	}
+}
+
+// WithNamePattern restricts the Unpackables to those whose names match the
+// regular expression; for example regexp.MustCompile(`^2025-`) selects only
+// the 2025 entries of a time series.  It can be combined with WithFilter.
+func WithNamePattern(re *regexp.Regexp) Option {
+	return func(o *options) {
+		o.namePattern = re
+	}
+}
+
+// WithLimit restricts the Unpackables to the first n, in the order they would
+// otherwise be returned, after any WithOffset has been applied.
+// Only these Unpackables are populated.
+func WithLimit(n int) Option {
+	return func(o *options) {
+		o.limit = n
+	}
+}
+
+// WithOffset skips the first n Unpackables, in the order they would otherwise
+// be returned.  Combined with WithLimit, this allows large JSON objects to be paged.
+func WithOffset(n int) Option {
+	return func(o *options) {
+		o.offset = n
+	}
+}
@@ -0,0 +1,189 @@
+package unpack
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Ordering specifies the order in which Unpackables are returned, by name
+type Ordering int
+
+const (
+	// OrderingAscending returns Unpackables in ascending order of name (the default)
+	OrderingAscending Ordering = iota
+	// OrderingDescending returns Unpackables in descending order of name
+	OrderingDescending
+	// OrderingDocument returns Unpackables in the order their names first
+	// appear in the JSON object, which requires its tokens to be scanned
+	OrderingDocument
+	// OrderingNatural returns Unpackables in ascending order of name, comparing
+	// runs of digits by their value so that "item2" precedes "item10".
+	// Names that are numbers are ordered by their value, before all others.
+	OrderingNatural
+)
+
+// Collator compares strings according to the rules of a locale.
+// *collate.Collator from golang.org/x/text/collate satisfies this interface.
+type Collator interface {
+	CompareString(a, b string) int
+}
+
+// sortNames sorts the names in place according to the ordering, using the
+// collator to compare names if there is one, and then, if there is one,
+// using the less function of the options
+func sortNames(names []string, o *options) {
+	switch {
+	case o.ordering == OrderingDocument:
+	case o.collator != nil:
+		sign := 1
+		if o.ordering == OrderingDescending {
+			sign = -1
+		}
+		sort.SliceStable(names, func(i, j int) bool {
+			if c := sign * o.collator.CompareString(names[i], names[j]); c != 0 {
+				return c < 0
+			}
+			// Break ties, so that the order does not depend on that of the map
+			return sign*strings.Compare(names[i], names[j]) < 0
+		})
+	case o.ordering == OrderingDescending:
+		sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	case o.ordering == OrderingNatural:
+		sort.Slice(names, func(i, j int) bool { return naturalLess(names[i], names[j]) })
+	default:
+		sort.Strings(names)
+	}
+
+	if o.less != nil {
+		sort.SliceStable(names, func(i, j int) bool { return o.less(names[i], names[j]) })
+	}
+}
+
+// sortTimes sorts the names in place by the times they represent when parsed
+// using the layout, in descending order if that is the ordering and otherwise
+// in ascending order.  Names representing the same time retain their sequence.
+func sortTimes(names []string, layout string, loc *time.Location, ordering Ordering) error {
+
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	times := make(map[string]time.Time, len(names))
+	for _, name := range names {
+		t, err := time.ParseInLocation(layout, name, loc)
+		if err != nil {
+			return fmt.Errorf("name %q is not a time: %w", name, err)
+		}
+		times[name] = t
+	}
+
+	sort.SliceStable(names, func(i, j int) bool {
+		if ordering == OrderingDescending {
+			return times[names[i]].After(times[names[j]])
+		}
+		return times[names[i]].Before(times[names[j]])
+	})
+
+	return nil
+}
+
+// orderNames returns the names arranged in the sequence specified by order.
+// Names not present in order are appended, retaining their existing sequence
+func orderNames(names, order []string) []string {
+
+	present := make(map[string]bool, len(names))
+	for _, name := range names {
+		present[name] = true
+	}
+
+	ret := make([]string, 0, len(names))
+	for _, name := range order {
+		if present[name] {
+			ret = append(ret, name)
+			delete(present, name)
+		}
+	}
+	for _, name := range names {
+		if present[name] {
+			ret = append(ret, name)
+		}
+	}
+	return ret
+}
+
+// naturalLess reports whether a precedes b in natural order.  Names that are
+// numbers precede all others, so that comparing numbers by value cannot
+// conflict with comparing runs of digits (as "2" < "1e3" < "1f" < "2" would).
+func naturalLess(a, b string) bool {
+
+	na, nb := isNumber(a), isNumber(b)
+	if na != nb {
+		return na
+	}
+
+	if na {
+		fa, _ := strconv.ParseFloat(a, 64)
+		fb, _ := strconv.ParseFloat(b, 64)
+		if fa != fb {
+			return fa < fb
+		}
+	}
+
+	x, y := a, b
+	for x != "" && y != "" {
+		dx, dy := isDigit(x[0]), isDigit(y[0])
+
+		switch {
+		case dx && dy:
+			var nx, ny string
+			nx, x = splitDigits(x)
+			ny, y = splitDigits(y)
+
+			// Compare the values of the runs, ignoring leading zeros
+			vx, vy := strings.TrimLeft(nx, "0"), strings.TrimLeft(ny, "0")
+			if len(vx) != len(vy) {
+				return len(vx) < len(vy)
+			}
+			if vx != vy {
+				return vx < vy
+			}
+		case x[0] != y[0]:
+			return x[0] < y[0]
+		default:
+			x, y = x[1:], y[1:]
+		}
+	}
+
+	if len(x) != len(y) {
+		return len(x) < len(y)
+	}
+
+	// Names that compare equal, such as "a01" and "a1", are ordered lexically
+	return a < b
+}
+
+// isNumber reports whether s is a decimal number, such as "-1.5" or "2e3"
+func isNumber(s string) bool {
+	if s == "" || strings.Trim(s, "0123456789.-+eE") != "" {
+		return false
+	}
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+// isDigit reports whether c is an ASCII digit
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// splitDigits returns the leading run of digits of s, and the remainder
+func splitDigits(s string) (string, string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
@@ -0,0 +1,146 @@
+package unpack
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNaturalLess(t *testing.T) {
+
+	type tc struct {
+		names  []string
+		sorted []string
+	}
+
+	tests := []tc{
+		{
+			names:  []string{"item10", "item2", "item1", "item02", "item"},
+			sorted: []string{"item", "item1", "item02", "item2", "item10"},
+		},
+		{
+			names:  []string{"10", "9", "-3", "1.5", "100", "2e1"},
+			sorted: []string{"-3", "1.5", "9", "10", "2e1", "100"},
+		},
+		{
+			names:  []string{"a1", "a01b", "a1b", "b", "A2"},
+			sorted: []string{"A2", "a1", "a01b", "a1b", "b"},
+		},
+		{
+			names:  []string{"v1.10.0", "v1.9.2", "v1.9.10", "v2"},
+			sorted: []string{"v1.9.2", "v1.9.10", "v1.10.0", "v2"},
+		},
+		{
+			names:  []string{"1f", "1e3", "2", "a1", "1"},
+			sorted: []string{"1", "2", "1e3", "1f", "a1"},
+		},
+	}
+
+	for i, test := range tests {
+		names := append([]string{}, test.names...)
+		sort.Slice(names, func(i, j int) bool { return naturalLess(names[i], names[j]) })
+		assert.Equal(t, test.sorted, names, "test %d", i)
+	}
+
+	// The order is the same whatever the order of the input
+	names := []string{"2", "1e3", "1f", "10", "x", "-1", "1.0", "01"}
+	for i := 0; i < 20; i++ {
+		shuffled := append([]string{}, names...)
+		for j := range shuffled {
+			k := (j*7 + i*3) % len(shuffled)
+			shuffled[j], shuffled[k] = shuffled[k], shuffled[j]
+		}
+		sort.Slice(shuffled, func(i, j int) bool { return naturalLess(shuffled[i], shuffled[j]) })
+		assert.Equal(t, []string{"-1", "01", "1.0", "2", "10", "1e3", "1f", "x"}, shuffled, "shuffle %d", i)
+	}
+
+	// naturalLess is transitive
+	for _, a := range names {
+		for _, b := range names {
+			for _, c := range names {
+				if naturalLess(a, b) && naturalLess(b, c) {
+					assert.True(t, naturalLess(a, c), "%s < %s < %s", a, b, c)
+				}
+			}
+		}
+	}
+}
+
+func TestUnpackOrderingNatural(t *testing.T) {
+
+	b := []byte(`{ "feed": { "item10": {}, "item2": {}, "item1": {} } }`)
+
+	u, err := Unpack(b, ttf{}, WithOrdering(OrderingNatural))
+	assert.Nil(t, err)
+
+	names := make([]string, len(u))
+	for i, uu := range u {
+		names[i] = uu.(*tt).n
+	}
+	assert.Equal(t, []string{"item1", "item2", "item10"}, names)
+}
+
+func TestUnpackTimeOrdering(t *testing.T) {
+
+	b := []byte(`{ "history": { "02-01-2023": {}, "31-12-2022": {}, "15-06-2022": {}, "01-02-2023": {} } }`)
+
+	names := func(u []Unpackable) []string {
+		ret := make([]string, len(u))
+		for i, uu := range u {
+			ret[i] = uu.(*tt).n
+		}
+		return ret
+	}
+
+	u, err := Unpack(b, ttf{}, WithTimeOrdering("02-01-2006", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"15-06-2022", "31-12-2022", "02-01-2023", "01-02-2023"}, names(u))
+
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		loc = time.FixedZone("EST", -5*60*60)
+	}
+
+	u, err = Unpack(b, ttf{}, WithTimeOrdering("02-01-2006", loc), WithOrdering(OrderingDescending), WithLimit(2))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"01-02-2023", "02-01-2023"}, names(u))
+
+	_, err = Unpack([]byte(`{ "history": { "02-01-2023": {}, "latest": {} } }`), ttf{}, WithTimeOrdering("02-01-2006", nil))
+	assert.NotNil(t, err)
+}
+
+// foldingCollator compares strings ignoring case and the accents of a few letters
+type foldingCollator struct{}
+
+func (foldingCollator) CompareString(a, b string) int {
+	fold := strings.NewReplacer("é", "e", "É", "e", "ü", "u", "Ö", "o").Replace
+	return strings.Compare(strings.ToLower(fold(a)), strings.ToLower(fold(b)))
+}
+
+func TestUnpackCollator(t *testing.T) {
+
+	b := []byte(`{ "cities": { "Zürich": {}, "Évian": {}, "Oslo": {}, "Örebro": {}, "Zurich": {}, "eton": {} } }`)
+
+	names := func(u []Unpackable) []string {
+		ret := make([]string, len(u))
+		for i, uu := range u {
+			ret[i] = uu.(*tt).n
+		}
+		return ret
+	}
+
+	u, err := Unpack(b, ttf{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Oslo", "Zurich", "Zürich", "eton", "Évian", "Örebro"}, names(u))
+
+	u, err = Unpack(b, ttf{}, WithCollator(foldingCollator{}))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"eton", "Évian", "Örebro", "Oslo", "Zurich", "Zürich"}, names(u))
+
+	u, err = Unpack(b, ttf{}, WithCollator(foldingCollator{}), WithOrdering(OrderingDescending))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Zürich", "Zurich", "Oslo", "Örebro", "Évian", "eton"}, names(u))
+}
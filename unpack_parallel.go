@@ -0,0 +1,50 @@
+package unpack
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// parallel calls fn for each index in [0, n) using the specified number of goroutines.
+// Indices are processed in increasing order, and no further indices are started once
+// an error occurs; the error for the lowest failing index is returned
+func parallel(n, workers int, fn func(i int) error) error {
+
+	if workers > n {
+		workers = n
+	}
+
+	var (
+		wg     sync.WaitGroup
+		next   int64 = -1
+		failed int32
+		errs   = make([]error, n)
+	)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for atomic.LoadInt32(&failed) == 0 {
+				i := int(atomic.AddInt64(&next, 1))
+				if i >= n {
+					return
+				}
+				if err := fn(i); err != nil {
+					errs[i] = err
+					atomic.StoreInt32(&failed, 1)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
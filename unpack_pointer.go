@@ -0,0 +1,72 @@
+package unpack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// parsePointer returns the reference tokens of the JSON Pointer, which must
+// begin with '/', unescaping "~1" and "~0" as described by RFC 6901
+func parsePointer(pointer string) []string {
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens
+}
+
+// formatPointer returns the JSON Pointer for the reference tokens
+func formatPointer(tokens []string) string {
+	var sb strings.Builder
+	for _, token := range tokens {
+		sb.WriteByte('/')
+		sb.WriteString(strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1"))
+	}
+	return sb.String()
+}
+
+// UnmarshalAtPointer returns the Unpackables, created by the factory, from
+// the JSON object that the JSON Pointer (RFC 6901), such as
+// "/response/data/items", refers to within the JSON.  Only JSON objects may
+// be navigated.  If the pointer does not refer to a value, because an
+// attribute is missing or a value is not an object, a *PathError is returned,
+// identifying the longest prefix of the pointer that does and matching
+// ErrPathNotFound.  The other options apply as for WithSectionPath.
+func UnmarshalAtPointer[F UnpackableFactory](ctx context.Context, pointer string, b []byte, fact F, opts ...Option) ([]Unpackable, error) {
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON Pointer %q", pointer)
+	}
+
+	o := newOptions(opts)
+
+	if o.maxBytes > 0 && len(b) > o.maxBytes {
+		return nil, &LimitError{Limit: "bytes", Max: o.maxBytes, Actual: len(b)}
+	}
+
+	tokens := parsePointer(pointer)
+
+	raw := json.RawMessage(b)
+	for i, token := range tokens {
+		var m map[string]json.RawMessage
+		if err := o.codec.Unmarshal(raw, &m); err != nil {
+			// Malformed JSON is reported as such, rather than as a missing path
+			if !json.Valid(raw) {
+				return nil, err
+			}
+			return nil, &PathError{Pointer: pointer, Prefix: formatPointer(tokens[:i])}
+		}
+		if m == nil {
+			return nil, &PathError{Pointer: pointer, Prefix: formatPointer(tokens[:i])}
+		}
+
+		var ok bool
+		if raw, ok = m[token]; !ok {
+			return nil, &PathError{Pointer: pointer, Prefix: formatPointer(tokens[:i])}
+		}
+	}
+
+	return UnpackContext(ctx, b, fact, append(opts[:len(opts):len(opts)], WithSectionPath(pointer))...)
+}
@@ -0,0 +1,65 @@
+package unpack
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshalAtPointer(t *testing.T) {
+
+	b := []byte(`
+{
+	"response": {
+		"status": "ok",
+		"data": {
+			"a/b": {
+				"2023-08-18": { "close": 140.5 },
+				"2023-08-21": { "close": 141.25 }
+			}
+		}
+	}
+}
+	`)
+
+	ctx := context.Background()
+
+	u, err := UnmarshalAtPointer(ctx, "/response/data/a~1b", b, quotef{})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(u))
+
+	u, err = UnmarshalAtPointer(ctx, "/response/data/a~1b", b, quotef{}, WithLimit(1))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(u))
+
+	_, err = UnmarshalAtPointer(ctx, "/response/info/items", b, quotef{})
+	assert.ErrorIs(t, err, ErrPathNotFound)
+	var pe *PathError
+	assert.ErrorAs(t, err, &pe)
+	assert.Equal(t, "/response", pe.Prefix)
+
+	// A value that is not an object cannot be navigated
+	_, err = UnmarshalAtPointer(ctx, "/response/status/items", b, quotef{})
+	assert.ErrorAs(t, err, &pe)
+	assert.Equal(t, "/response/status", pe.Prefix)
+
+	_, err = UnmarshalAtPointer(ctx, "/history", b, quotef{})
+	assert.ErrorAs(t, err, &pe)
+	assert.Equal(t, "", pe.Prefix)
+
+	_, err = UnmarshalAtPointer(ctx, "response/data", b, quotef{})
+	assert.NotNil(t, err)
+
+	// Malformed JSON is not reported as a missing path, so can be retried
+	_, err = UnmarshalAtPointer(ctx, "/response/data/a~1b", b[:40], quotef{})
+	assert.False(t, errors.Is(err, ErrPathNotFound))
+	assert.True(t, IsTransient(err))
+
+	// Factories that are pointers are used as provided
+	f := NewTypeFactory("kind").Register("quote", func() Unpackable { return new(quote) })
+	u, err = UnmarshalAtPointer(ctx, "/items", []byte(`{ "items": { "IBM": { "kind": "quote", "close": 140.5 } } }`), f)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(u))
+}
@@ -0,0 +1,70 @@
+package unpack
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// TypeFactory is a PolymorphicUnpackableFactory that creates an Unpackable of
+// the type registered for the value of a discriminator attribute of each JSON
+// object, so that a collection of mixed types (such as events or resources)
+// can be unpacked together.  Callers can switch on the types of the returned
+// Unpackables, or convert them to a common interface.
+type TypeFactory struct {
+	discriminator string
+	types         map[string]func() Unpackable
+}
+
+// NewTypeFactory returns a TypeFactory selecting types using the value of the
+// discriminator attribute, which defaults to "type" if ""
+func NewTypeFactory(discriminator string) *TypeFactory {
+	if discriminator == "" {
+		discriminator = defaultDiscriminator
+	}
+	return &TypeFactory{
+		discriminator: discriminator,
+		types:         map[string]func() Unpackable{},
+	}
+}
+
+// Register adds fn as the function creating the Unpackables for JSON objects
+// whose discriminator attribute has the value key, returning the TypeFactory
+// so that calls can be chained.  It is not safe to call Register while the
+// TypeFactory is being used by Unpack.
+func (f *TypeFactory) Register(key string, fn func() Unpackable) *TypeFactory {
+	f.types[key] = fn
+	return f
+}
+
+// New returns nil, as the type of an Unpackable cannot be selected without
+// its JSON object; Unpack uses NewFor instead
+func (f *TypeFactory) New() Unpackable {
+	return nil
+}
+
+// NewFor returns a new Unpackable of the type registered for the value of the
+// discriminator attribute of the JSON object, returning an error if there is
+// no such attribute or no type is registered for its value
+func (f *TypeFactory) NewFor(name string, raw json.RawMessage) (Unpackable, error) {
+
+	var attrs map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &attrs); err != nil {
+		return nil, err
+	}
+
+	b, ok := attrs[f.discriminator]
+	if !ok {
+		return nil, fmt.Errorf("no %q attribute to select the type", f.discriminator)
+	}
+
+	var key string
+	if err := json.Unmarshal(b, &key); err != nil {
+		return nil, fmt.Errorf("%q attribute: %w", f.discriminator, err)
+	}
+
+	fn, ok := f.types[key]
+	if !ok {
+		return nil, fmt.Errorf("no type is registered for %q", key)
+	}
+	return fn(), nil
+}
@@ -0,0 +1,84 @@
+package unpack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type event interface {
+	Unpackable
+	Name() string
+}
+
+type login struct {
+	name string
+	User string `json:"user"`
+}
+
+func (l *login) SetName(name string) {
+	l.name = name
+}
+
+func (l *login) Name() string {
+	return l.name
+}
+
+type purchase struct {
+	name   string
+	Amount float64 `json:"amount"`
+}
+
+func (p *purchase) SetName(name string) {
+	p.name = name
+}
+
+func (p *purchase) Name() string {
+	return p.name
+}
+
+func TestTypeFactory(t *testing.T) {
+
+	b := []byte(`
+{
+	"events": {
+		"e1": { "type": "login", "user": "ann" },
+		"e2": { "type": "purchase", "amount": 9.99 },
+		"e3": { "type": "login", "user": "bob" }
+	}
+}
+	`)
+
+	fact := NewTypeFactory("").
+		Register("login", func() Unpackable { return new(login) }).
+		Register("purchase", func() Unpackable { return new(purchase) })
+
+	u, err := Unpack(b, fact)
+	assert.Nil(t, err)
+	assert.Equal(t, []Unpackable{
+		&login{name: "e1", User: "ann"},
+		&purchase{name: "e2", Amount: 9.99},
+		&login{name: "e3", User: "bob"},
+	}, u)
+
+	events := make([]event, len(u))
+	for i, e := range u {
+		events[i] = e.(event)
+	}
+	assert.Equal(t, "e2", events[1].Name())
+
+	var ue *UnpackError
+	_, err = Unpack([]byte(`{ "events": { "e1": { "type": "logout" } } }`), fact)
+	assert.ErrorAs(t, err, &ue)
+	assert.Equal(t, `"e1": no type is registered for "logout"`, err.Error())
+
+	_, err = Unpack([]byte(`{ "events": { "e1": { "kind": "login" } } }`), fact)
+	assert.NotNil(t, err)
+
+	u, err = Unpack([]byte(`{ "events": { "e1": { "kind": "login", "user": "cat" } } }`), NewTypeFactory("kind").Register("login", func() Unpackable { return new(login) }), WithStrictFields())
+	assert.NotNil(t, err)
+
+	u, err = Unpack([]byte(`{ "events": { "e1": { "kind": "login", "user": "cat" } } }`), NewTypeFactory("kind").Register("login", func() Unpackable { return new(login) }))
+	assert.Nil(t, err)
+	assert.Equal(t, &login{name: "e1", User: "cat"}, u[0])
+}
@@ -0,0 +1,126 @@
+package unpack
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+)
+
+var (
+	// ErrNotPooled is returned when releasing an item that was not acquired from the ItemPool
+	ErrNotPooled = errors.New("item not acquired from pool")
+	// ErrReleased is returned when releasing an item that has already been released
+	ErrReleased = errors.New("item already released")
+)
+
+// ItemPool recycles Unpackables, so that services which fully process the
+// Unpackables of each request can reuse them rather than allocating new ones.
+// It implements UnpackableFactory, so it can be passed to Unpack directly.
+// Each item has a generation, incremented when it is released, allowing holders
+// of an item to check that it has not been released and reused since they
+// acquired it.  The zero value is not usable until NewItem is set.
+// Items that are acquired but never released remain tracked by the pool.
+type ItemPool[T interface {
+	comparable
+	Unpackable
+}] struct {
+	// NewItem creates an item when none are available for reuse
+	NewItem func() T
+	// Reset clears an item when it is released; if nil, the value that
+	// a pointer item points to is set to its zero value
+	Reset func(item T)
+	// Debug prevents released items from being reused, so that any use of
+	// an item after its release cannot observe another request's data and
+	// is reported by Valid.  Released items are then retained by the pool.
+	Debug bool
+
+	mu    sync.Mutex
+	idle  []T
+	items map[T]*poolEntry
+}
+
+type poolEntry struct {
+	gen      uint64
+	acquired bool
+}
+
+// Acquire returns an item for use, reusing a released item if available
+func (p *ItemPool[T]) Acquire() T {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var item T
+	if n := len(p.idle); n > 0 {
+		item = p.idle[n-1]
+		p.idle = p.idle[:n-1]
+	} else {
+		item = p.NewItem()
+	}
+
+	if p.items == nil {
+		p.items = map[T]*poolEntry{}
+	}
+	e, ok := p.items[item]
+	if !ok {
+		e = &poolEntry{}
+		p.items[item] = e
+	}
+	e.acquired = true
+
+	return item
+}
+
+// New returns Acquire, allowing the pool to be used as an UnpackableFactory
+func (p *ItemPool[T]) New() Unpackable {
+	return p.Acquire()
+}
+
+// Release resets the item and returns it to the pool for reuse.
+// It returns ErrNotPooled if the item was not acquired from the pool, and
+// ErrReleased if it has been released since it was last acquired.
+func (p *ItemPool[T]) Release(item T) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	e, ok := p.items[item]
+	if !ok {
+		return ErrNotPooled
+	}
+	if !e.acquired {
+		return ErrReleased
+	}
+	e.acquired = false
+	e.gen++
+
+	if p.Reset != nil {
+		p.Reset(item)
+	} else if v := reflect.ValueOf(item); v.Kind() == reflect.Pointer && !v.IsNil() {
+		v.Elem().Set(reflect.Zero(v.Elem().Type()))
+	}
+
+	if !p.Debug {
+		p.idle = append(p.idle, item)
+	}
+	return nil
+}
+
+// Generation returns the number of times the item has been released
+func (p *ItemPool[T]) Generation(item T) uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if e, ok := p.items[item]; ok {
+		return e.gen
+	}
+	return 0
+}
+
+// Valid reports whether the item is acquired and has not been released
+// since its generation was gen, meaning that it is still safe to use
+func (p *ItemPool[T]) Valid(item T, gen uint64) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	e, ok := p.items[item]
+	return ok && e.acquired && e.gen == gen
+}
@@ -0,0 +1,58 @@
+package unpack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestItemPool(t *testing.T) {
+
+	created := 0
+	pool := &ItemPool[*city]{
+		NewItem: func() *city {
+			created++
+			return new(city)
+		},
+	}
+
+	b := []byte(`{ "cities": { "London": { "capital": true, "population": 9000000 }, "Paris": { "population": 2100000 } } }`)
+
+	u, err := Unpack(b, pool)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, created)
+
+	london := u[0].(*city)
+	gen := pool.Generation(london)
+	assert.True(t, pool.Valid(london, gen))
+
+	for _, item := range u {
+		assert.Nil(t, pool.Release(item.(*city)))
+	}
+	assert.False(t, pool.Valid(london, gen))
+	assert.Equal(t, &city{}, london)
+	assert.Equal(t, gen+1, pool.Generation(london))
+
+	assert.Equal(t, ErrReleased, pool.Release(london))
+	assert.Equal(t, ErrNotPooled, pool.Release(new(city)))
+
+	// Released items are reused, reset before being populated again
+	u, err = Unpack([]byte(`{ "cities": { "Lyon": { "population": 520000 } } }`), pool)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, created)
+	assert.Equal(t, &city{name: "Lyon", Population: 520000}, u[0])
+
+	debug := &ItemPool[*city]{
+		NewItem: func() *city { return new(city) },
+		Reset:   func(c *city) { c.Population = -1 },
+		Debug:   true,
+	}
+
+	c := debug.Acquire()
+	gen = debug.Generation(c)
+	assert.Nil(t, debug.Release(c))
+	assert.Equal(t, -1, c.Population)
+	assert.False(t, debug.Valid(c, gen))
+	assert.True(t, c != debug.Acquire())
+	assert.Equal(t, ErrReleased, debug.Release(c))
+}
@@ -0,0 +1,178 @@
+package unpack
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// maxSamples is the number of distinct values retained for each field by Profile
+const maxSamples = 5
+
+// FieldProfile summarises the values of a field across a set of Unpackables
+type FieldProfile struct {
+	Attribute string   // attribute name the field is populated from
+	Type      string   // Go type of the field
+	Count     int      // number of Unpackables
+	Nulls     int      // number of nil pointers, slices, maps and interfaces
+	Distinct  int      // number of distinct values that are not null
+	Min       *float64 // smallest value, for numeric fields with values that are not null
+	Max       *float64 // largest value, for numeric fields with values that are not null
+	Samples   []string // up to five distinct values, in the order first seen
+}
+
+// NullRate returns the proportion of the Unpackables for which the field is null
+func (f FieldProfile) NullRate() float64 {
+	if f.Count == 0 {
+		return 0
+	}
+	return float64(f.Nulls) / float64(f.Count)
+}
+
+// ProfileReport summarises the values of the fields of a set of Unpackables
+type ProfileReport struct {
+	Type   string         // Go type of the Unpackables
+	Count  int            // number of Unpackables
+	Fields []FieldProfile // in ascending order of attribute name
+}
+
+// String returns the report as a table
+func (r *ProfileReport) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "type: %s\ncount: %d\n", r.Type, r.Count)
+
+	w := tabwriter.NewWriter(&sb, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "attribute\ttype\tnulls\tdistinct\tmin\tmax\tsamples")
+	for _, f := range r.Fields {
+		fmt.Fprintf(w, "%s\t%s\t%.1f%%\t%d\t%s\t%s\t%s\n",
+			f.Attribute, f.Type, 100*f.NullRate(), f.Distinct, formatBound(f.Min), formatBound(f.Max), strings.Join(f.Samples, ", "))
+	}
+	w.Flush()
+
+	return sb.String()
+}
+
+// formatBound returns the bound as a string, or "-" if there is none
+func formatBound(f *float64) string {
+	if f == nil {
+		return "-"
+	}
+	return fmt.Sprint(*f)
+}
+
+// Profile returns statistics for each populated field of the Unpackables, such
+// as the proportion that are null and the number of distinct values, to give
+// an indication of the quality of a freshly unpacked data set.  The
+// Unpackables must all be pointers to the same struct type.
+func Profile(items []Unpackable) (*ProfileReport, error) {
+
+	r := &ProfileReport{Count: len(items)}
+	if len(items) == 0 {
+		return r, nil
+	}
+
+	t := reflect.TypeOf(items[0])
+	r.Type = t.String()
+	if t.Kind() != reflect.Pointer || t.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot profile %v: not a pointer to a struct", t)
+	}
+
+	p := cachedPlan(t, "")
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	attrs := make([]string, 0, len(p.index))
+	for attr := range p.index {
+		attrs = append(attrs, attr)
+	}
+	sort.Strings(attrs)
+
+	r.Fields = make([]FieldProfile, len(attrs))
+	seen := make([]map[string]bool, len(attrs))
+	for i, attr := range attrs {
+		r.Fields[i] = FieldProfile{
+			Attribute: attr,
+			Type:      t.Elem().FieldByIndex(p.index[attr]).Type.String(),
+			Count:     len(items),
+		}
+		seen[i] = map[string]bool{}
+	}
+
+	for _, item := range items {
+		if reflect.TypeOf(item) != t {
+			return nil, fmt.Errorf("cannot profile %T with %v", item, t)
+		}
+
+		v := reflect.ValueOf(item).Elem()
+		for i, attr := range attrs {
+			profileValue(&r.Fields[i], seen[i], fieldValue(v, p.index[attr]))
+		}
+	}
+
+	return r, nil
+}
+
+// fieldValue returns the field of v with the index, dereferencing pointers,
+// or the zero Value if the field is null
+func fieldValue(v reflect.Value, index []int) reflect.Value {
+	for _, i := range index {
+		for v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+
+	for {
+		switch v.Kind() {
+		case reflect.Pointer, reflect.Interface:
+			if v.IsNil() {
+				return reflect.Value{}
+			}
+			v = v.Elem()
+		case reflect.Map, reflect.Slice:
+			if v.IsNil() {
+				return reflect.Value{}
+			}
+			return v
+		default:
+			return v
+		}
+	}
+}
+
+// profileValue adds the value to the profile of its field
+func profileValue(f *FieldProfile, seen map[string]bool, v reflect.Value) {
+
+	if !v.IsValid() {
+		f.Nulls++
+		return
+	}
+
+	s := fmt.Sprint(v.Interface())
+	if !seen[s] {
+		seen[s] = true
+		f.Distinct++
+		if len(f.Samples) < maxSamples {
+			f.Samples = append(f.Samples, s)
+		}
+	}
+
+	n, ok := number(v)
+	if !ok {
+		return
+	}
+
+	if f.Min == nil || n < *f.Min {
+		f.Min = &n
+	}
+	if f.Max == nil || n > *f.Max {
+		m := n
+		f.Max = &m
+	}
+}
@@ -0,0 +1,56 @@
+package unpack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfile(t *testing.T) {
+
+	b := []byte(`
+{
+	"history": {
+		"a": { "date": "2023-08-18", "settled": "2023-08-20T00:00:00Z", "close": 140.5 },
+		"b": { "date": "2023-08-21", "close": 141.25 },
+		"c": { "date": "2023-08-22", "close": 140.5 }
+	}
+}
+	`)
+
+	u, err := Unpack(b, quotef{})
+	if err != nil {
+		t.Fatalf("Unexpected parse failure: %v", err)
+	}
+
+	r, err := Profile(u)
+	assert.Nil(t, err)
+	assert.Equal(t, "*unpack.quote", r.Type)
+	assert.Equal(t, 3, r.Count)
+	assert.Equal(t, 4, len(r.Fields))
+
+	c := r.Fields[0]
+	assert.Equal(t, "close", c.Attribute)
+	assert.Equal(t, "float64", c.Type)
+	assert.Equal(t, 0, c.Nulls)
+	assert.Equal(t, 2, c.Distinct)
+	assert.Equal(t, 140.5, *c.Min)
+	assert.Equal(t, 141.25, *c.Max)
+	assert.Equal(t, []string{"140.5", "141.25"}, c.Samples)
+
+	s := r.Fields[3]
+	assert.Equal(t, "settled", s.Attribute)
+	assert.Equal(t, 2, s.Nulls)
+	assert.InDelta(t, 2.0/3, s.NullRate(), 1e-9)
+	assert.Equal(t, 1, s.Distinct)
+	assert.Nil(t, s.Min)
+
+	assert.Contains(t, r.String(), "settled    *time.Time  66.7%  1 ")
+
+	r, err = Profile(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(r.Fields))
+
+	_, err = Profile([]Unpackable{new(quote), new(capital)})
+	assert.NotNil(t, err)
+}
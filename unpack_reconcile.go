@@ -0,0 +1,152 @@
+package unpack
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Mismatch describes an attribute whose values differ between the
+// Unpackables with the same key from two sources
+type Mismatch struct {
+	Key       string
+	Attribute string
+	Primary   interface{} // value from the primary source, or nil if null
+	Secondary interface{} // value from the secondary source, or nil if null
+}
+
+func (m Mismatch) String() string {
+	return fmt.Sprintf("%q: attribute %q: %v != %v", m.Key, m.Attribute, m.Primary, m.Secondary)
+}
+
+// Reconciliation describes the differences between two sets of Unpackables
+type Reconciliation struct {
+	Matched       int        // number of keys present in both sources with no mismatches
+	MismatchKeys  int        // number of keys present in both sources with at least one mismatch
+	OnlyPrimary   []string   // keys present only in the primary source, in ascending order
+	OnlySecondary []string   // keys present only in the secondary source, in ascending order
+	Mismatches    []Mismatch // in ascending order of key, then attribute
+}
+
+// Consistent reports whether the sources hold the same keys, with no mismatches
+func (r *Reconciliation) Consistent() bool {
+	return len(r.OnlyPrimary) == 0 && len(r.OnlySecondary) == 0 && len(r.Mismatches) == 0
+}
+
+// Reconcile compares the Unpackables from two sources, such as an incumbent
+// data vendor and a new one, pairing them by the key that key returns for
+// each (typically their name) and comparing each populated field.  Numeric
+// values are treated as equal if they differ by no more than tolerance;
+// other values must be equal.  The Unpackables must be pointers to structs.
+func Reconcile[T Unpackable](primary, secondary []T, key func(T) string, tolerance float64) (*Reconciliation, error) {
+
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() != reflect.Pointer || t.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot reconcile %v: not a pointer to a struct", t)
+	}
+
+	p := cachedPlan(t, "")
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	attrs := make([]string, 0, len(p.index))
+	for attr := range p.index {
+		attrs = append(attrs, attr)
+	}
+	sort.Strings(attrs)
+
+	seconds := make(map[string]T, len(secondary))
+	for _, s := range secondary {
+		seconds[key(s)] = s
+	}
+
+	r := &Reconciliation{}
+
+	keys := make([]string, 0, len(primary))
+	primaries := make(map[string]T, len(primary))
+	for _, u := range primary {
+		k := key(u)
+		if _, ok := seconds[k]; !ok {
+			r.OnlyPrimary = append(r.OnlyPrimary, k)
+			continue
+		}
+		if _, ok := primaries[k]; !ok {
+			keys = append(keys, k)
+		}
+		primaries[k] = u
+	}
+	sort.Strings(keys)
+	sort.Strings(r.OnlyPrimary)
+
+	for k := range seconds {
+		if _, ok := primaries[k]; !ok {
+			r.OnlySecondary = append(r.OnlySecondary, k)
+		}
+	}
+	sort.Strings(r.OnlySecondary)
+
+	for _, k := range keys {
+		pv := reflect.ValueOf(primaries[k]).Elem()
+		sv := reflect.ValueOf(seconds[k]).Elem()
+
+		n := len(r.Mismatches)
+		for _, attr := range attrs {
+			a, b := fieldValue(pv, p.index[attr]), fieldValue(sv, p.index[attr])
+			if !reconciled(a, b, tolerance) {
+				r.Mismatches = append(r.Mismatches, Mismatch{Key: k, Attribute: attr, Primary: valueOf(a), Secondary: valueOf(b)})
+			}
+		}
+
+		if len(r.Mismatches) > n {
+			r.MismatchKeys++
+		} else {
+			r.Matched++
+		}
+	}
+
+	return r, nil
+}
+
+// reconciled reports whether the values, either of which may be the zero
+// Value if null, are equal, allowing numbers to differ by the tolerance
+func reconciled(a, b reflect.Value, tolerance float64) bool {
+	if !a.IsValid() || !b.IsValid() {
+		return a.IsValid() == b.IsValid()
+	}
+
+	if x, ok := number(a); ok {
+		if y, ok := number(b); ok {
+			return math.Abs(x-y) <= tolerance
+		}
+	}
+
+	if a.Type() == timeType && b.Type() == timeType {
+		return a.Interface().(time.Time).Equal(b.Interface().(time.Time))
+	}
+
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
+
+// number returns the value of v as a float64, if it is numeric
+func number(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+// valueOf returns the value held by v, or nil if v is the zero Value
+func valueOf(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	return v.Interface()
+}
@@ -0,0 +1,66 @@
+package unpack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconcile(t *testing.T) {
+
+	incumbent := []byte(`
+{
+	"history": {
+		"2023-08-17": { "date": "2023-08-17", "close": 139.0 },
+		"2023-08-18": { "date": "2023-08-18", "close": 140.5 },
+		"2023-08-21": { "date": "2023-08-21", "close": 141.25 },
+		"2023-08-22": { "date": "2023-08-22", "close": 142.0 }
+	}
+}
+	`)
+
+	vendor := []byte(`
+{
+	"history": {
+		"2023-08-18": { "date": "2023-08-18", "close": 140.504 },
+		"2023-08-21": { "date": "2023-08-21", "close": 141.75, "settled": "2023-08-23T00:00:00Z" },
+		"2023-08-22": { "date": "2023-08-22", "close": 142.0 },
+		"2023-08-23": { "date": "2023-08-23", "close": 142.5 }
+	}
+}
+	`)
+
+	var primary, secondary []*quote
+
+	u, err := Unpack(incumbent, quotef{})
+	assert.Nil(t, err)
+	for _, q := range u {
+		primary = append(primary, q.(*quote))
+	}
+
+	u, err = Unpack(vendor, quotef{})
+	assert.Nil(t, err)
+	for _, q := range u {
+		secondary = append(secondary, q.(*quote))
+	}
+
+	name := func(q *quote) string { return q.name }
+
+	r, err := Reconcile(primary, secondary, name, 0.01)
+	assert.Nil(t, err)
+	assert.False(t, r.Consistent())
+	assert.Equal(t, 2, r.Matched)
+	assert.Equal(t, 1, r.MismatchKeys)
+	assert.Equal(t, []string{"2023-08-17"}, r.OnlyPrimary)
+	assert.Equal(t, []string{"2023-08-23"}, r.OnlySecondary)
+	assert.Equal(t, 2, len(r.Mismatches))
+	assert.Equal(t, Mismatch{Key: "2023-08-21", Attribute: "close", Primary: 141.25, Secondary: 141.75}, r.Mismatches[0])
+	assert.Equal(t, "settled", r.Mismatches[1].Attribute)
+	assert.Nil(t, r.Mismatches[1].Primary)
+	assert.Equal(t, `"2023-08-21": attribute "close": 141.25 != 141.75`, r.Mismatches[0].String())
+
+	r, err = Reconcile(primary, primary, name, 0)
+	assert.Nil(t, err)
+	assert.True(t, r.Consistent())
+	assert.Equal(t, 4, r.Matched)
+}
@@ -0,0 +1,189 @@
+package unpack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Recorder writes each JSON object passed to it into a directory, one file
+// per JSON object, so that regression tests can be built from real payloads
+// and later fed back using Replay.  Use WithRecorder to record the JSON
+// objects passed to UnpackContext and UnpackOne.
+type Recorder struct {
+	dir    string
+	redact func(b []byte) ([]byte, error)
+
+	mu sync.Mutex
+	n  int
+}
+
+// NewRecorder returns a Recorder writing to dir, which is created if it does
+// not exist.  If redact is not nil, it is applied to each JSON object before it
+// is written, allowing sensitive values to be removed (see RedactAttributes).
+// Numbering of the files continues after the highest already recorded in dir,
+// and an existing file is never overwritten.
+func NewRecorder(dir string, redact func(b []byte) ([]byte, error)) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	files, err := recordings(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	n := 0
+	for _, file := range files {
+		if i, ok := recordingNumber(file); ok && i > n {
+			n = i
+		}
+	}
+
+	return &Recorder{
+		dir:    dir,
+		redact: redact,
+		n:      n,
+	}, nil
+}
+
+// recordingNumber returns the number of a file named as by Recorder
+func recordingNumber(file string) (int, bool) {
+	if len(file) != 11 || !strings.HasSuffix(file, ".json") {
+		return 0, false
+	}
+	digits := file[:6]
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+	}
+	n, err := strconv.Atoi(digits)
+	return n, err == nil
+}
+
+// Record writes the JSON object to the next file in the directory
+func (r *Recorder) Record(b []byte) error {
+	if r.redact != nil {
+		var err error
+		if b, err = r.redact(b); err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.n++
+
+	// Never overwrite an existing recording
+	f, err := os.OpenFile(filepath.Join(r.dir, fmt.Sprintf("%06d.json", r.n)), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// WithRecorder records each JSON object passed to UnpackContext or UnpackOne
+// before it is unpacked.  If it cannot be recorded, the error is returned.
+func WithRecorder(r *Recorder) Option {
+	return func(o *options) {
+		o.recorder = r
+	}
+}
+
+// Replay calls fn with the name and contents of each file in dir written by
+// a Recorder, in the order they were recorded.
+func Replay(ctx context.Context, dir string, fn func(name string, b []byte) error) error {
+
+	files, err := recordings(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		b, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			return err
+		}
+		if err := fn(file, b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recordings returns the names of the recorded files in dir, in order
+func recordings(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+			files = append(files, e.Name())
+		}
+	}
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// RedactAttributes returns a function for use with NewRecorder that replaces
+// the values of the named attributes, at any depth, with "REDACTED".
+// The attributes of the returned JSON are in ascending order of their names.
+func RedactAttributes(names ...string) func(b []byte) ([]byte, error) {
+	redacted := make(map[string]bool, len(names))
+	for _, name := range names {
+		redacted[name] = true
+	}
+
+	var redact func(v interface{}) interface{}
+	redact = func(v interface{}) interface{} {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			for k, e := range t {
+				if redacted[k] {
+					t[k] = "REDACTED"
+				} else {
+					t[k] = redact(e)
+				}
+			}
+		case []interface{}:
+			for i, e := range t {
+				t[i] = redact(e)
+			}
+		}
+		return v
+	}
+
+	return func(b []byte) ([]byte, error) {
+		d := json.NewDecoder(bytes.NewReader(b))
+		d.UseNumber()
+
+		var v interface{}
+		if err := d.Decode(&v); err != nil {
+			return nil, err
+		}
+
+		return json.Marshal(redact(v))
+	}
+}
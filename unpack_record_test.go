@@ -0,0 +1,90 @@
+package unpack
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordReplay(t *testing.T) {
+
+	dir := t.TempDir()
+
+	rec, err := NewRecorder(dir, RedactAttributes("capital"))
+	assert.Nil(t, err)
+
+	ctx := context.Background()
+
+	payloads := []string{
+		`{ "countries": { "UK": { "capital": "London", "population": { "London": 9000000 } } } }`,
+		`{ "countries": { "FR": { "capital": "Paris" } } }`,
+	}
+
+	for _, p := range payloads {
+		_, err := UnpackContext(ctx, []byte(p), countryf{}, WithRecorder(rec))
+		assert.Nil(t, err)
+	}
+	_, err = UnpackOne(ctx, []byte(payloads[1]), countryf{}, "FR", WithRecorder(rec))
+	assert.Nil(t, err)
+
+	var (
+		names     []string
+		countries []string
+		london    []int
+	)
+
+	err = Replay(ctx, dir, func(name string, b []byte) error {
+		names = append(names, name)
+
+		u, err := Unpack(b, countryf{})
+		if err == nil {
+			countries = append(countries, u[0].(*country).Capital)
+			london = append(london, u[0].(*country).Population["London"])
+		}
+		return err
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"000001.json", "000002.json", "000003.json"}, names)
+	assert.Equal(t, []string{"REDACTED", "REDACTED", "REDACTED"}, countries)
+	assert.Equal(t, []int{9000000, 0, 0}, london)
+
+	// Numbering continues from existing recordings
+	rec, err = NewRecorder(dir, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, rec.Record([]byte(payloads[0])))
+
+	names = nil
+	stop := errors.New("stop")
+	err = Replay(ctx, dir, func(name string, b []byte) error {
+		names = append(names, name)
+		if name == "000004.json" {
+			return stop
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 4, len(names))
+
+	// Numbering continues after the highest recording, without overwriting
+	assert.Nil(t, os.Remove(filepath.Join(dir, "000002.json")))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "notes.json"), []byte(`{}`), 0o644))
+	rec, err = NewRecorder(dir, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, rec.Record([]byte(payloads[1])))
+	b, err := os.ReadFile(filepath.Join(dir, "000004.json"))
+	assert.Nil(t, err)
+	assert.Equal(t, payloads[0], string(b))
+	b, err = os.ReadFile(filepath.Join(dir, "000005.json"))
+	assert.Nil(t, err)
+	assert.Equal(t, payloads[1], string(b))
+
+	// Unrecordable payloads are not unpacked
+	rec, err = NewRecorder(t.TempDir(), RedactAttributes())
+	assert.Nil(t, err)
+	_, err = Unpack([]byte(`{`), countryf{}, WithRecorder(rec))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,100 @@
+package unpack
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// defaultDiscriminator is the attribute that selects the concrete type of an
+// interface field, unless another is specified by WithDiscriminator
+const defaultDiscriminator = "type"
+
+// registry holds, for each interface type, the concrete types registered by
+// RegisterInterfaceImpl, by the value of the discriminator that selects them
+var registry = struct {
+	sync.RWMutex
+	impls map[reflect.Type]map[string]reflect.Type
+}{impls: map[reflect.Type]map[string]reflect.Type{}}
+
+// RegisterInterfaceImpl registers C as the concrete type used to populate
+// fields of the interface type I when the discriminator attribute (see
+// WithDiscriminator) of their JSON object has the value key.  If *C, rather
+// than C, implements I then the field is set to a *C.  Registering another
+// type with the same I and key replaces the first.
+// RegisterInterfaceImpl panics if I is not an interface type or if neither C
+// nor *C implements it.
+func RegisterInterfaceImpl[I, C any](key string) {
+
+	it := reflect.TypeOf((*I)(nil)).Elem()
+	ct := reflect.TypeOf((*C)(nil)).Elem()
+
+	if it.Kind() != reflect.Interface {
+		panic(fmt.Sprintf("unpack: %v is not an interface", it))
+	}
+	if !ct.Implements(it) {
+		if !reflect.PointerTo(ct).Implements(it) {
+			panic(fmt.Sprintf("unpack: %v does not implement %v", ct, it))
+		}
+		ct = reflect.PointerTo(ct)
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	if registry.impls[it] == nil {
+		registry.impls[it] = map[string]reflect.Type{}
+	}
+	registry.impls[it][key] = ct
+}
+
+// registered returns the concrete type registered for the interface type and key
+func registered(it reflect.Type, key string) (reflect.Type, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	ct, ok := registry.impls[it][key]
+	return ct, ok
+}
+
+// decodeInterface returns a value of the concrete type registered for the
+// interface type, selected by the discriminator attribute of the JSON object,
+// populated from the JSON object
+func decodeInterface(b json.RawMessage, it reflect.Type, o *options) (reflect.Value, error) {
+
+	var attrs map[string]json.RawMessage
+	if err := o.codec.Unmarshal(b, &attrs); err != nil {
+		return reflect.Value{}, err
+	}
+
+	raw, ok := attrs[o.discriminator]
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("no %q attribute to select the type of %v", o.discriminator, it)
+	}
+
+	var key string
+	if err := o.codec.Unmarshal(raw, &key); err != nil {
+		return reflect.Value{}, fmt.Errorf("%q attribute: %w", o.discriminator, err)
+	}
+
+	ct, ok := registered(it, key)
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("no type of %v is registered for %q", it, key)
+	}
+
+	var v reflect.Value
+	if ct.Kind() == reflect.Pointer {
+		v = reflect.New(ct.Elem())
+	} else {
+		v = reflect.New(ct)
+	}
+	if err := o.codec.Unmarshal(b, v.Interface()); err != nil {
+		return reflect.Value{}, err
+	}
+	if ct.Kind() != reflect.Pointer {
+		v = v.Elem()
+	}
+
+	return v, nil
+}
@@ -0,0 +1,84 @@
+package unpack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type shape interface {
+	Area() float64
+}
+
+type square struct {
+	Side float64 `json:"side"`
+}
+
+func (s square) Area() float64 {
+	return s.Side * s.Side
+}
+
+type rect struct {
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+func (r *rect) Area() float64 {
+	return r.Width * r.Height
+}
+
+type plot struct {
+	name  string
+	Shape shape  `json:"shape"`
+	Owner string `json:"owner"`
+}
+
+func (p *plot) SetName(name string) {
+	p.name = name
+}
+
+type plotf struct{}
+
+func (f plotf) New() Unpackable {
+	return new(plot)
+}
+
+func TestRegisterInterfaceImpl(t *testing.T) {
+
+	RegisterInterfaceImpl[shape, square]("square")
+	RegisterInterfaceImpl[shape, rect]("rect")
+
+	b := []byte(`
+{
+	"plots": {
+		"P1": { "owner": "Ann", "SHAPE": { "type": "square", "side": 2 } },
+		"P2": { "owner": "Bob", "shape": { "type": "rect", "width": 2, "height": 3 } },
+		"P3": { "owner": "Cat", "shape": null }
+	}
+}
+	`)
+
+	u, err := Unpack(b, plotf{})
+	assert.Nil(t, err)
+	assert.Equal(t, &plot{name: "P1", Shape: square{Side: 2}, Owner: "Ann"}, u[0])
+	assert.Equal(t, &plot{name: "P2", Shape: &rect{Width: 2, Height: 3}, Owner: "Bob"}, u[1])
+	assert.Equal(t, &plot{name: "P3", Owner: "Cat"}, u[2])
+
+	u, err = Unpack([]byte(`{ "plots": { "P1": { "shape": { "kind": "square", "side": 3 } } } }`), plotf{}, WithDiscriminator("kind"), WithStrictFields())
+	assert.Nil(t, err)
+	assert.Equal(t, 9.0, u[0].(*plot).Shape.Area())
+
+	var ue *UnpackError
+	_, err = Unpack([]byte(`{ "plots": { "P1": { "shape": { "type": "circle" } } } }`), plotf{})
+	assert.ErrorAs(t, err, &ue)
+	assert.Equal(t, "shape", ue.Path)
+
+	_, err = Unpack([]byte(`{ "plots": { "P1": { "shape": { "side": 3 } } } }`), plotf{})
+	assert.ErrorAs(t, err, &ue)
+	assert.Equal(t, `no "type" attribute to select the type of unpack.shape`, ue.Err.Error())
+
+	assert.Contains(t, ExplainPlan[*plot](), `shape, registered type selected by "type"`)
+
+	assert.Panics(t, func() { RegisterInterfaceImpl[square, rect]("x") })
+	assert.Panics(t, func() { RegisterInterfaceImpl[shape, plot]("x") })
+}
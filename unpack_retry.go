@@ -0,0 +1,187 @@
+package unpack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by a Breaker that is not allowing attempts
+var ErrCircuitOpen = errors.New("circuit open")
+
+// CircuitBreaker is consulted by DecodeWithRetry before each attempt and
+// informed of its outcome, so that repeated failures can prevent further
+// attempts, protecting the provider and downstream systems during an outage
+type CircuitBreaker interface {
+	// Allow returns an error if an attempt should not be made
+	Allow() error
+	// Success records an attempt that succeeded
+	Success()
+	// Failure records an attempt that failed
+	Failure(err error)
+}
+
+// Breaker is a CircuitBreaker that opens after a number of consecutive
+// failures, and allows attempts again once the cooldown has elapsed,
+// reopening on the next failure and closing on the next success.  It is safe
+// for concurrent use, so may be shared by all the callers of a provider.
+type Breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openedAt  time.Time
+	now       func() time.Time
+}
+
+// NewBreaker returns a Breaker that opens after threshold consecutive failures,
+// for the cooldown duration
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &Breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// Allow returns ErrCircuitOpen if the Breaker is open
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures >= b.threshold && b.now().Sub(b.openedAt) < b.cooldown {
+		return ErrCircuitOpen
+	}
+	return nil
+}
+
+// Success closes the Breaker
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+}
+
+// Failure counts the failure, opening the Breaker if the threshold is reached
+func (b *Breaker) Failure(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openedAt = b.now()
+	}
+}
+
+// RetryPolicy determines how DecodeWithRetry retries failures
+type RetryPolicy struct {
+	MaxAttempts int                  // total number of attempts; values below 1 are treated as 1
+	Backoff     time.Duration        // wait before the second attempt, doubling for each attempt thereafter
+	MaxBackoff  time.Duration        // if positive, the longest wait between attempts
+	Retryable   func(err error) bool // reports whether the error should be retried; IsTransient if nil
+	Breaker     CircuitBreaker       // if not nil, consulted before each attempt
+}
+
+// IsTransient reports whether the error is of a class that a later attempt
+// may not encounter: a *ProviderError (see WithErrorSections), or JSON that
+// ended unexpectedly, as when a response is truncated
+func IsTransient(err error) bool {
+
+	var pe *ProviderError
+	if errors.As(err, &pe) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var se *json.SyntaxError
+	return errors.As(err, &se) && strings.Contains(se.Error(), "unexpected end of JSON input")
+}
+
+// DecodeWithRetry calls fetch and unpacks the JSON it returns as UnpackContext,
+// repeating both, after the wait specified by the policy, while the error
+// returned by either is retryable and attempts remain.  The error of the
+// final attempt is returned, or the context's error if it is done whilst
+// waiting.  WithErrorSections allows provider error messages to be retried.
+// If the policy's Breaker does not allow an attempt, its error is returned
+// without calling fetch.  Only failures of the provider count as failures
+// for the Breaker: an error returned by fetch, or a *ProviderError; other
+// errors, such as JSON that cannot be unpacked, count as a success, and an
+// attempt ended by the context is not counted.  Unpackables returned together
+// with an error (see WithContinueOnError) are returned without retrying.
+func DecodeWithRetry[F UnpackableFactory](ctx context.Context, fetch func() ([]byte, error), fact F, policy RetryPolicy, opts ...Option) ([]Unpackable, error) {
+
+	retryable := policy.Retryable
+	if retryable == nil {
+		retryable = IsTransient
+	}
+
+	wait := policy.Backoff
+
+	for attempt := 1; ; attempt++ {
+		if policy.Breaker != nil {
+			if err := policy.Breaker.Allow(); err != nil {
+				return nil, err
+			}
+		}
+
+		items, fetched, err := fetchAndUnpack(ctx, fetch, fact, opts)
+
+		// An abandoned attempt says nothing about the provider
+		if err != nil && ctx.Err() != nil {
+			return items, err
+		}
+
+		// Unpackables returned with an error (see WithContinueOnError) are a response
+		ok := err == nil || items != nil
+
+		if policy.Breaker != nil {
+			var pe *ProviderError
+			if !ok && (!fetched || errors.As(err, &pe)) {
+				policy.Breaker.Failure(err)
+			} else {
+				policy.Breaker.Success()
+			}
+		}
+
+		if ok || attempt >= policy.MaxAttempts || !retryable(err) {
+			return items, err
+		}
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+
+		wait *= 2
+		if policy.MaxBackoff > 0 && wait > policy.MaxBackoff {
+			wait = policy.MaxBackoff
+		}
+	}
+}
+
+// fetchAndUnpack makes a single attempt for DecodeWithRetry, reporting
+// whether fetch returned the JSON
+func fetchAndUnpack[F UnpackableFactory](ctx context.Context, fetch func() ([]byte, error), fact F, opts []Option) ([]Unpackable, bool, error) {
+
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+
+	b, err := fetch()
+	if err != nil {
+		return nil, false, err
+	}
+
+	items, err := UnpackContext(ctx, b, fact, opts...)
+	return items, true, err
+}
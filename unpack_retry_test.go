@@ -0,0 +1,154 @@
+package unpack
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeWithRetry(t *testing.T) {
+
+	responses := [][]byte{
+		[]byte(`{ "Note": "API call frequency exceeded" }`),
+		[]byte(`{ "history": { "2023-08-18": { "clo`),
+		[]byte(`{ "history": { "2023-08-18": { "close": 140.5 } } }`),
+	}
+
+	ctx := context.Background()
+	policy := RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}
+
+	calls := 0
+	fetch := func() ([]byte, error) {
+		b := responses[calls]
+		calls++
+		return b, nil
+	}
+
+	u, err := DecodeWithRetry(ctx, fetch, quotef{}, policy, WithErrorSections("Note"))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(u))
+	assert.Equal(t, 3, calls)
+
+	// The error of the final attempt is returned
+	calls = 0
+	policy.MaxAttempts = 2
+	_, err = DecodeWithRetry(ctx, fetch, quotef{}, policy, WithErrorSections("Note"))
+	assert.True(t, IsTransient(err))
+	assert.Equal(t, 2, calls)
+
+	// Errors that are not transient are not retried
+	calls = 0
+	_, err = DecodeWithRetry(ctx, func() ([]byte, error) {
+		calls++
+		return []byte(`{ "history": { "2023-08-18": { "close": "high" } } }`), nil
+	}, quotef{}, policy)
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, calls)
+
+	unavailable := errors.New("service unavailable")
+	calls = 0
+	_, err = DecodeWithRetry(ctx, func() ([]byte, error) {
+		calls++
+		return nil, unavailable
+	}, quotef{}, RetryPolicy{MaxAttempts: 3, Retryable: func(err error) bool { return errors.Is(err, unavailable) }})
+	assert.ErrorIs(t, err, unavailable)
+	assert.Equal(t, 3, calls)
+
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	calls = 0
+	_, err = DecodeWithRetry(cctx, fetch, quotef{}, RetryPolicy{MaxAttempts: 3, Backoff: time.Minute}, WithErrorSections("Note"))
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestDecodeWithRetryBreaker(t *testing.T) {
+
+	now := time.Date(2023, 8, 21, 9, 0, 0, 0, time.UTC)
+	br := NewBreaker(2, time.Minute)
+	br.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	policy := RetryPolicy{MaxAttempts: 3, Breaker: br}
+
+	body := []byte(`{ "Error Message": "Service unavailable" }`)
+	calls := 0
+	fetch := func() ([]byte, error) {
+		calls++
+		return body, nil
+	}
+
+	// The Breaker opens after the second failure, preventing the third attempt
+	_, err := DecodeWithRetry(ctx, fetch, quotef{}, policy, WithErrorSections("Error Message"))
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, 2, calls)
+
+	_, err = DecodeWithRetry(ctx, fetch, quotef{}, policy, WithErrorSections("Error Message"))
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, 2, calls)
+
+	// After the cooldown an attempt is allowed, and its failure reopens the Breaker
+	now = now.Add(time.Minute)
+	_, err = DecodeWithRetry(ctx, fetch, quotef{}, policy, WithErrorSections("Error Message"))
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, 3, calls)
+
+	// Success closes the Breaker
+	now = now.Add(time.Minute)
+	body = []byte(`{ "history": { "2023-08-18": { "close": 140.5 } } }`)
+	u, err := DecodeWithRetry(ctx, fetch, quotef{}, policy, WithErrorSections("Error Message"))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(u))
+	assert.Nil(t, br.Allow())
+}
+
+func TestDecodeWithRetryBreakerCounts(t *testing.T) {
+
+	ctx := context.Background()
+	br := NewBreaker(1, time.Minute)
+	policy := RetryPolicy{MaxAttempts: 3, Breaker: br}
+
+	// Unpackables returned with errors are a healthy response
+	calls := 0
+	u, err := DecodeWithRetry(ctx, func() ([]byte, error) {
+		calls++
+		return []byte(`{ "history": { "2023-08-18": { "close": 140.5 }, "2023-08-21": { "close": "x" } } }`), nil
+	}, quotef{}, policy, WithContinueOnError())
+	assert.Equal(t, 1, len(u))
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, calls)
+	assert.Nil(t, br.Allow())
+
+	// Cancellation is not counted, and stops further attempts
+	cctx, cancel := context.WithCancel(ctx)
+	calls = 0
+	_, err = DecodeWithRetry(cctx, func() ([]byte, error) {
+		calls++
+		cancel()
+		return nil, context.Canceled
+	}, quotef{}, RetryPolicy{MaxAttempts: 3, Breaker: br, Retryable: func(error) bool { return true }})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 1, calls)
+	assert.Nil(t, br.Allow())
+
+	// JSON that cannot be unpacked is not a failure of the provider
+	calls = 0
+	_, err = DecodeWithRetry(ctx, func() ([]byte, error) {
+		calls++
+		return []byte(`{ "history": { "2023-08-18": { "close": "x" } } }`), nil
+	}, quotef{}, RetryPolicy{MaxAttempts: 3, Breaker: br, Retryable: func(error) bool { return true }})
+	var ue *UnpackError
+	assert.ErrorAs(t, err, &ue)
+	assert.Equal(t, 3, calls)
+	assert.Nil(t, br.Allow())
+
+	// An error from fetch is
+	_, err = DecodeWithRetry(ctx, func() ([]byte, error) {
+		return nil, errors.New("connection refused")
+	}, quotef{}, policy)
+	assert.Equal(t, "connection refused", err.Error())
+	assert.ErrorIs(t, br.Allow(), ErrCircuitOpen)
+}
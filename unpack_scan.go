@@ -0,0 +1,250 @@
+package unpack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// errNotObject is returned when the JSON to be scanned is not an object
+var errNotObject = errors.New("JSON is not an object")
+
+// errStopScan is returned by a scanNames callback to end the scan early
+var errStopScan = errors.New("stop scan")
+
+// scanNames scans the tokens of the JSON object, calling fn with the decoder
+// positioned at the JSON object of each Unpackable, which fn must either read
+// or skip.  Of the options, only WithOrderFrom, WithSection (or
+// WithSectionPath), WithSkipSections and WithMaxBytes are used.
+func scanNames(ctx context.Context, b []byte, o *options, fn func(d *json.Decoder, name string) error) error {
+
+	if o.maxBytes > 0 && len(b) > o.maxBytes {
+		return &LimitError{Limit: "bytes", Max: o.maxBytes, Actual: len(b)}
+	}
+
+	d := json.NewDecoder(bytes.NewReader(b))
+
+	if t, err := d.Token(); err != nil {
+		return err
+	} else if t != json.Delim('{') {
+		return errNotObject
+	}
+
+	count, sections := 0, 0
+
+	for d.More() {
+		t, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		if (o.orderFrom != "" && t == o.orderFrom) || (o.section != "" && t != o.section) || o.isSkipped(t) {
+			if err := skipValue(d); err != nil {
+				return err
+			}
+			continue
+		}
+
+		sections++
+		if sections > 1 {
+			return errors.New("incorrectly formed JSON")
+		}
+
+		if err := descend(d, o.sectionPath); err != nil {
+			return err
+		}
+
+		t, err = d.Token()
+		if err != nil {
+			return err
+		}
+		if t == nil {
+			if len(o.sectionPath) > 0 {
+				return nil
+			}
+			continue
+		}
+		if t != json.Delim('{') {
+			return errNotObject
+		}
+
+		for d.More() {
+			if count%1024 == 0 {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+			}
+			count++
+
+			t, err := d.Token()
+			if err != nil {
+				return err
+			}
+			name, ok := t.(string)
+			if !ok {
+				return fmt.Errorf("unexpected token %v", t)
+			}
+
+			if err := fn(d, name); err != nil {
+				if err == errStopScan {
+					return nil
+				}
+				return err
+			}
+		}
+
+		if _, err := d.Token(); err != nil {
+			return err
+		}
+
+		if len(o.sectionPath) > 0 {
+			// The remainder of the enclosing objects is not required
+			return nil
+		}
+	}
+
+	if sections != 1 {
+		if o.section != "" {
+			return fmt.Errorf("section %q not found", o.section)
+		}
+		return errors.New("incorrectly formed JSON")
+	}
+
+	return nil
+}
+
+// descend positions the decoder at the value of the attribute at the path
+// within the JSON object that is the decoder's next value (see WithSectionPath)
+func descend(d *json.Decoder, path []string) error {
+	for _, name := range path {
+		if t, err := d.Token(); err != nil {
+			return err
+		} else if t != json.Delim('{') {
+			return errNotObject
+		}
+
+		for {
+			if !d.More() {
+				return fmt.Errorf("section %q not found", name)
+			}
+
+			t, err := d.Token()
+			if err != nil {
+				return err
+			}
+			if t == name {
+				break
+			}
+			if err := skipValue(d); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isSkipped reports whether the token is a top level attribute specified by WithSkipSections
+func (o *options) isSkipped(t json.Token) bool {
+	for _, name := range o.skipped {
+		if t == name {
+			return true
+		}
+	}
+	return false
+}
+
+// scanObject calls fn with each attribute name and value of the JSON object,
+// in the order they appear, including any duplicated names.  A JSON null is
+// treated as an empty object, consistent with json.Unmarshal into a map.
+func scanObject(b []byte, fn func(name string, value json.RawMessage) error) error {
+
+	d := json.NewDecoder(bytes.NewReader(b))
+
+	t, err := d.Token()
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		return nil
+	}
+	if t != json.Delim('{') {
+		return errNotObject
+	}
+
+	for d.More() {
+		t, err := d.Token()
+		if err != nil {
+			return err
+		}
+		name, ok := t.(string)
+		if !ok {
+			return fmt.Errorf("unexpected token %v", t)
+		}
+
+		var value json.RawMessage
+		if err := d.Decode(&value); err != nil {
+			return err
+		}
+
+		if err := fn(name, value); err != nil {
+			return err
+		}
+	}
+
+	_, err = d.Token()
+	return err
+}
+
+// countNames returns the number of distinct attribute names of the JSON
+// object, stopping once max is exceeded, without retaining their values
+func countNames(b []byte, max int) (int, error) {
+
+	d := json.NewDecoder(bytes.NewReader(b))
+
+	t, err := d.Token()
+	if err != nil {
+		return 0, err
+	}
+	if t == nil {
+		return 0, nil
+	}
+	if t != json.Delim('{') {
+		return 0, errNotObject
+	}
+
+	seen := map[json.Token]bool{}
+	for len(seen) <= max && d.More() {
+		t, err := d.Token()
+		if err != nil {
+			return 0, err
+		}
+		if err := skipValue(d); err != nil {
+			return 0, err
+		}
+		seen[t] = true
+	}
+
+	return len(seen), nil
+}
+
+// skipValue reads the next JSON value from the decoder, discarding it
+func skipValue(d *json.Decoder) error {
+	n := 0
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t {
+		case json.Delim('{'), json.Delim('['):
+			n++
+		case json.Delim('}'), json.Delim(']'):
+			n--
+		}
+		if n == 0 {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package unpack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrNameNotFound is returned when a requested name is not within the JSON object
+var ErrNameNotFound = errors.New("name not found")
+
+// UnpackHead returns the first n Unpackables, in the order that Unpack would
+// return them given the same options.  Only these Unpackables are populated.
+// An error is returned if n is negative.
+func UnpackHead[F UnpackableFactory](ctx context.Context, b []byte, fact F, n int, opts ...Option) ([]Unpackable, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("invalid count of %d", n)
+	}
+	return UnpackContext(ctx, b, fact, append(opts[:len(opts):len(opts)], withSelect(func(names []string) []string {
+		if n < len(names) {
+			return names[:n]
+		}
+		return names
+	}))...)
+}
+
+// UnpackTail returns the last n Unpackables, in the order that Unpack would
+// return them given the same options.  Only these Unpackables are populated.
+// An error is returned if n is negative.
+func UnpackTail[F UnpackableFactory](ctx context.Context, b []byte, fact F, n int, opts ...Option) ([]Unpackable, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("invalid count of %d", n)
+	}
+	return UnpackContext(ctx, b, fact, append(opts[:len(opts):len(opts)], withSelect(func(names []string) []string {
+		if n < len(names) {
+			return names[len(names)-n:]
+		}
+		return names
+	}))...)
+}
+
+// withSelect restricts the Unpackables to be populated to those whose
+// names are returned by fn, which is passed the names in order
+func withSelect(fn func(names []string) []string) Option {
+	return func(o *options) {
+		o.selectFn = fn
+	}
+}
+
+// UnpackOne returns the Unpackable with the specified name, locating it by
+// scanning the tokens of the JSON object so that no other Unpackable is decoded.
+// If the name is not present, an error wrapping ErrNameNotFound is returned.
+// Options relating to the order or selection of names are not used.
+func UnpackOne[F UnpackableFactory](ctx context.Context, b []byte, fact F, name string, opts ...Option) (Unpackable, error) {
+
+	o := newOptions(opts)
+
+	if o.recorder != nil {
+		if err := o.recorder.Record(b); err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		raw   json.RawMessage
+		found bool
+	)
+
+	err := scanNames(ctx, b, o, func(d *json.Decoder, n string) error {
+		if n != name {
+			return skipValue(d)
+		}
+		if found && o.duplicateNames == DuplicateNameError {
+			return fmt.Errorf("duplicate name %q", n)
+		}
+		found = true
+
+		if err := d.Decode(&raw); err != nil {
+			return err
+		}
+		if o.duplicateNames == DuplicateNameFirst {
+			return errStopScan
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, fmt.Errorf("%q: %w", name, ErrNameNotFound)
+	}
+
+	return populate(newFunc(fact), name, nil, map[string]json.RawMessage{name: raw}, o)
+}
@@ -0,0 +1,231 @@
+package unpack
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnpackHeadTail(t *testing.T) {
+
+	b := []byte(`
+{
+	"history": {
+		"2023-08-17": { "close": 139 },
+		"2023-08-18": { "close": 140.5 },
+		"2023-08-21": { "close": 141.25 },
+		"bad": { "close": "n/a" }
+	}
+}
+	`)
+
+	names := func(u []Unpackable) []string {
+		n := make([]string, len(u))
+		for i, uu := range u {
+			n[i] = uu.(*quote).name
+		}
+		return n
+	}
+
+	ctx := context.Background()
+
+	u, err := UnpackHead(ctx, b, quotef{}, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2023-08-17", "2023-08-18"}, names(u))
+
+	u, err = UnpackHead(ctx, b, quotef{}, 1, WithOrdering(OrderingDescending), WithResumeAfter("bad"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2023-08-21"}, names(u))
+
+	u, err = UnpackTail(ctx, b, quotef{}, 2, WithOrdering(OrderingDescending))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2023-08-18", "2023-08-17"}, names(u))
+
+	u, err = UnpackHead(ctx, b, quotef{}, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(u))
+
+	_, err = UnpackTail(ctx, b, quotef{}, 10)
+	assert.NotNil(t, err)
+
+	u, err = UnpackHead(ctx, b, quotef{}, -1)
+	assert.NotNil(t, err)
+	assert.Nil(t, u)
+
+	u, err = UnpackTail(ctx, b, quotef{}, -1)
+	assert.NotNil(t, err)
+	assert.Nil(t, u)
+
+	// The caller's options are not modified
+	opts := make([]Option, 1, 2)
+	opts[0] = WithKeys("2023-08-17", "2023-08-18")
+	_, err = UnpackHead(ctx, b, quotef{}, 1, opts...)
+	assert.Nil(t, err)
+	_, err = UnpackTail(ctx, b, quotef{}, 1, opts...)
+	assert.Nil(t, err)
+	assert.True(t, opts[:2][1] == nil)
+}
+
+func TestUnpackOne(t *testing.T) {
+
+	b := []byte(`
+{
+	"history": {
+		"2023-08-17": { "close": 139 },
+		"2023-08-18": { "close": 140.5 },
+		"2023-08-21": { "close": 141.25 },
+		"2023-08-18": { "close": 140.75 },
+		"bad": { "close": "n/a" }
+	}
+}
+	`)
+
+	ctx := context.Background()
+
+	u, err := UnpackOne(ctx, b, quotef{}, "2023-08-21")
+	assert.Nil(t, err)
+	assert.Equal(t, "2023-08-21", u.(*quote).name)
+	assert.Equal(t, 141.25, u.(*quote).Close)
+
+	u, err = UnpackOne(ctx, b, quotef{}, "2023-08-18")
+	assert.Nil(t, err)
+	assert.Equal(t, 140.75, u.(*quote).Close)
+
+	u, err = UnpackOne(ctx, b, quotef{}, "2023-08-18", WithDuplicateNamePolicy(DuplicateNameFirst))
+	assert.Nil(t, err)
+	assert.Equal(t, 140.5, u.(*quote).Close)
+
+	_, err = UnpackOne(ctx, b, quotef{}, "2023-08-18", WithDuplicateNamePolicy(DuplicateNameError))
+	assert.NotNil(t, err)
+
+	_, err = UnpackOne(ctx, b, quotef{}, "2023-08-22")
+	assert.ErrorIs(t, err, ErrNameNotFound)
+
+	_, err = UnpackOne(ctx, b, quotef{}, "bad")
+	assert.NotNil(t, err)
+}
+
+func TestUnpackFilter(t *testing.T) {
+
+	b := []byte(`
+{
+	"history": {
+		"2023-08-17": { "close": 139 },
+		"2023-08-18": { "close": 140.5 },
+		"2023-08-21": { "close": 141.25 },
+		"bad": { "close": "n/a" }
+	}
+}
+	`)
+
+	after := func(name string) bool {
+		return name > "2023-08-17" && name < "bad"
+	}
+
+	u, err := Unpack(b, quotef{}, WithFilter(after))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(u))
+	assert.Equal(t, "2023-08-18", u[0].(*quote).name)
+	assert.Equal(t, "2023-08-21", u[1].(*quote).name)
+
+	names, err := Keys(context.Background(), b, WithFilter(after), WithOrdering(OrderingDescending))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2023-08-21", "2023-08-18"}, names)
+}
+
+func TestUnpackKeys(t *testing.T) {
+
+	b := []byte(`
+{
+	"countries": {
+		"UK": { "capital": "London" },
+		"FR": { "capital": "Paris" },
+		"US": { "capital": "Washington" },
+		"DE": { "capital": "Berlin" }
+	}
+}
+	`)
+
+	u, err := Unpack(b, countryf{}, WithKeys("US", "UK"))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(u))
+	assert.Equal(t, "London", u[0].(*country).Capital)
+	assert.Equal(t, "Washington", u[1].(*country).Capital)
+
+	_, err = Unpack(b, countryf{}, WithKeys("US", "IT"))
+	assert.ErrorIs(t, err, ErrNameNotFound)
+
+	u, err = Unpack(b, countryf{}, WithKeys("US", "IT"), WithSkipMissingKeys())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(u))
+	assert.Equal(t, "US", u[0].(*country).name)
+}
+
+func TestUnpackNamePattern(t *testing.T) {
+
+	b := []byte(`
+{
+	"history": {
+		"2024-12-31": { "close": 139 },
+		"2025-01-02": { "close": 140.5 },
+		"2025-01-03": { "close": 141.25 },
+		"latest": { "close": 141.25 }
+	}
+}
+	`)
+
+	u, err := Unpack(b, quotef{}, WithNamePattern(regexp.MustCompile(`^2025-`)))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(u))
+	assert.Equal(t, "2025-01-02", u[0].(*quote).name)
+	assert.Equal(t, "2025-01-03", u[1].(*quote).name)
+
+	u, err = Unpack(b, quotef{}, WithNamePattern(regexp.MustCompile(`^\d{4}-`)), WithFilter(func(name string) bool {
+		return name < "2025-01-03"
+	}))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(u))
+	assert.Equal(t, "2024-12-31", u[0].(*quote).name)
+}
+
+func TestUnpackLimitOffset(t *testing.T) {
+
+	b := []byte(`
+{
+	"countries": {
+		"UK": {},
+		"FR": {},
+		"US": {},
+		"DE": {},
+		"IT": {}
+	}
+}
+	`)
+
+	type tc struct {
+		opts  []Option
+		names []string
+	}
+
+	tests := []tc{
+		{opts: []Option{WithLimit(2)}, names: []string{"DE", "FR"}},
+		{opts: []Option{WithOffset(2), WithLimit(2)}, names: []string{"IT", "UK"}},
+		{opts: []Option{WithOffset(4), WithLimit(2)}, names: []string{"US"}},
+		{opts: []Option{WithOffset(5), WithLimit(2)}, names: []string{}},
+		{opts: []Option{WithOffset(9)}, names: []string{}},
+		{opts: []Option{WithOffset(1), WithLimit(2), WithOrdering(OrderingDescending)}, names: []string{"UK", "IT"}},
+	}
+
+	for i, test := range tests {
+		u, err := Unpack(b, countryf{}, test.opts...)
+		assert.Nil(t, err, "test %d", i)
+
+		names := []string{}
+		for _, uu := range u {
+			names = append(names, uu.(*country).name)
+		}
+		assert.Equal(t, test.names, names, "test %d", i)
+	}
+}
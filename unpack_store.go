@@ -0,0 +1,175 @@
+package unpack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrSnapshotNotFound is returned by a Store when there is no snapshot with the name
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
+// Store persists named snapshots, each holding the JSON of a set of
+// Unpackables, so that applications can save and reload data sets through
+// a single abstraction (see Save and Load)
+type Store interface {
+	// Put stores the JSON as the snapshot with the name, replacing any existing snapshot
+	Put(name string, b []byte) error
+	// Get returns the JSON of the snapshot with the name, or ErrSnapshotNotFound
+	Get(name string) ([]byte, error)
+	// List returns the names of the snapshots, in ascending order
+	List() ([]string, error)
+}
+
+// Save stores the Unpackables, by name, as the snapshot with the name, in the
+// form that Unpack reads, as the value of the top level attribute section.
+// Of the options, only WithCodec is used.
+func Save(s Store, name, section string, items map[string]Unpackable, opts ...Option) error {
+
+	b, err := newOptions(opts).codec.Marshal(map[string]map[string]Unpackable{section: items})
+	if err != nil {
+		return err
+	}
+
+	return s.Put(name, b)
+}
+
+// Load returns the Unpackables of the snapshot with the name, as UnpackContext
+func Load[F UnpackableFactory](ctx context.Context, s Store, name string, fact F, opts ...Option) ([]Unpackable, error) {
+
+	b, err := s.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return UnpackContext(ctx, b, fact, opts...)
+}
+
+// MemoryStore is a Store that holds snapshots in memory.  It is safe for concurrent use.
+type MemoryStore struct {
+	mu        sync.RWMutex
+	snapshots map[string][]byte
+}
+
+// NewMemoryStore returns an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{snapshots: map[string][]byte{}}
+}
+
+// Put stores a copy of the JSON as the snapshot with the name
+func (m *MemoryStore) Put(name string, b []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.snapshots[name] = append([]byte(nil), b...)
+	return nil
+}
+
+// Get returns a copy of the JSON of the snapshot with the name
+func (m *MemoryStore) Get(name string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	b, ok := m.snapshots[name]
+	if !ok {
+		return nil, fmt.Errorf("%q: %w", name, ErrSnapshotNotFound)
+	}
+	return append([]byte(nil), b...), nil
+}
+
+// List returns the names of the snapshots, in ascending order
+func (m *MemoryStore) List() ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, 0, len(m.snapshots))
+	for name := range m.snapshots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// FileStore is a Store that holds each snapshot as a file in a directory,
+// named using the name of the snapshot with the extension ".json"
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore returns a FileStore using dir, which is created if it does not exist
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// Put writes the JSON to the file of the snapshot with the name, replacing
+// it atomically so that a concurrent Get never reads a partial snapshot
+func (f *FileStore) Put(name string, b []byte) error {
+	path, err := f.path(name)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(f.dir, ".snapshot-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Get reads the JSON from the file of the snapshot with the name
+func (f *FileStore) Get(name string) ([]byte, error) {
+	path, err := f.path(name)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%q: %w", name, ErrSnapshotNotFound)
+	}
+	return b, err
+}
+
+// List returns the names of the snapshots in the directory, in ascending order
+func (f *FileStore) List() ([]string, error) {
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+			names = append(names, strings.TrimSuffix(e.Name(), ".json"))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// path returns the path of the file of the snapshot with the name,
+// rejecting names that would refer to a file outside the directory
+func (f *FileStore) path(name string) (string, error) {
+	if name == "" || name != filepath.Base(name) || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid snapshot name %q", name)
+	}
+	return filepath.Join(f.dir, name+".json"), nil
+}
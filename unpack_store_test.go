@@ -0,0 +1,51 @@
+package unpack
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore(t *testing.T) {
+
+	fs, err := NewFileStore(filepath.Join(t.TempDir(), "snapshots"))
+	if err != nil {
+		t.Fatalf("Unexpected failure: %v", err)
+	}
+
+	ctx := context.Background()
+
+	for _, s := range []Store{NewMemoryStore(), fs} {
+		err := Save(s, "readings", "readings", map[string]Unpackable{
+			"2023-08-18": &reading{Value: 1.5},
+			"2023-08-21": &reading{Value: 2.5},
+		})
+		assert.Nil(t, err)
+
+		assert.Nil(t, s.Put("empty", []byte(`{ "readings": {} }`)))
+
+		names, err := s.List()
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"empty", "readings"}, names)
+
+		u, err := Load(ctx, s, "readings", readingf{})
+		assert.Nil(t, err)
+		assert.Equal(t, []Unpackable{
+			&reading{name: "2023-08-18", original: "2023-08-18", Value: 1.5},
+			&reading{name: "2023-08-21", original: "2023-08-21", Value: 2.5},
+		}, u)
+
+		_, err = Load(ctx, s, "missing", readingf{})
+		assert.ErrorIs(t, err, ErrSnapshotNotFound)
+	}
+
+	assert.NotNil(t, fs.Put("../escape", []byte(`{}`)))
+
+	// Temporary files are not left behind, or listed
+	entries, err := os.ReadDir(fs.dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(entries))
+}
@@ -0,0 +1,119 @@
+package unpack
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ReadStream reads successive JSON objects of the form accepted by Unpack
+// from r (for example the body of a chunked HTTP response), calling fn with
+// the Unpackables of each as it arrives.  It returns nil when r is exhausted,
+// or the first error from reading, unpacking or fn.  If an object's
+// Unpackables are returned together with an error (see WithContinueOnError),
+// they are still passed to fn, and the errors of all such objects are
+// returned together with any that stops it (as an Errors if there is more
+// than one).  The context is checked
+// between objects; to interrupt a blocked read, r must also be closed, as
+// happens to an HTTP response body when the request's context is cancelled.
+func ReadStream[F UnpackableFactory](ctx context.Context, r io.Reader, fact F, fn func(items []Unpackable) error, opts ...Option) error {
+
+	d := newOptions(opts).codec.NewDecoder(r)
+
+	var errs error
+	for {
+		if err := ctx.Err(); err != nil {
+			return join(errs, err)
+		}
+
+		var raw json.RawMessage
+		if err := d.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				return errs
+			}
+			return join(errs, err)
+		}
+
+		items, err := UnpackContext(ctx, raw, fact, opts...)
+		if err != nil && items == nil {
+			return join(errs, err)
+		}
+		errs = join(errs, err)
+
+		if err := fn(items); err != nil {
+			return join(errs, err)
+		}
+	}
+}
+
+// ReadEvents reads server-sent events from r (for example the body of an
+// HTTP response with content type text/event-stream), where the data of each
+// event is a JSON object of the form accepted by Unpack, calling fn with the
+// event type ("message" unless specified by the event) and its Unpackables.
+// Events without data are ignored.  Errors and cancellation are as for ReadStream.
+func ReadEvents[F UnpackableFactory](ctx context.Context, r io.Reader, fact F, fn func(event string, items []Unpackable) error, opts ...Option) error {
+
+	var (
+		s     = bufio.NewScanner(r)
+		event string
+		data  []string
+		errs  error
+	)
+
+	// Allow for large events, such as a complete snapshot of a feed
+	s.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+	dispatch := func() error {
+		defer func() {
+			event, data = "", nil
+		}()
+
+		if len(data) == 0 {
+			return nil
+		}
+		if event == "" {
+			event = "message"
+		}
+
+		items, err := UnpackContext(ctx, []byte(strings.Join(data, "\n")), fact, opts...)
+		if err != nil && items == nil {
+			return err
+		}
+		errs = join(errs, err)
+
+		return fn(event, items)
+	}
+
+	for s.Scan() {
+		if err := ctx.Err(); err != nil {
+			return join(errs, err)
+		}
+
+		line := s.Text()
+		if line == "" {
+			if err := dispatch(); err != nil {
+				return join(errs, err)
+			}
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return join(errs, err)
+	}
+
+	// An event is only dispatched once terminated by a blank line
+	return errs
+}
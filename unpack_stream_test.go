@@ -0,0 +1,111 @@
+package unpack
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadStream(t *testing.T) {
+
+	r := strings.NewReader(`
+{ "quotes": { "IBM": { "close": 140.5 }, "AAPL": { "close": 175.1 } } }
+{ "quotes": { "IBM": { "close": 140.75 } } }
+	`)
+
+	var received [][]string
+	err := ReadStream(context.Background(), r, quotef{}, func(items []Unpackable) error {
+		var names []string
+		for _, item := range items {
+			names = append(names, item.(*quote).name)
+		}
+		received = append(received, names)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"AAPL", "IBM"}, {"IBM"}}, received)
+
+	err = ReadStream(context.Background(), strings.NewReader(`{ "quotes": {} } { "quotes": `), quotef{}, func(items []Unpackable) error {
+		return nil
+	})
+	assert.NotNil(t, err)
+
+	stop := errors.New("stop")
+	err = ReadStream(context.Background(), strings.NewReader(`{ "quotes": {} }`), quotef{}, func(items []Unpackable) error {
+		return stop
+	})
+	assert.Equal(t, stop, err)
+
+	// Unpackables returned with errors are still passed to fn
+	received = nil
+	err = ReadStream(context.Background(), strings.NewReader(`
+{ "quotes": { "IBM": { "close": "x" }, "AAPL": { "close": 175.1 } } }
+{ "quotes": { "IBM": { "close": 140.75 } } }
+	`), quotef{}, func(items []Unpackable) error {
+		var names []string
+		for _, item := range items {
+			names = append(names, item.(*quote).name)
+		}
+		received = append(received, names)
+		return nil
+	}, WithContinueOnError())
+	var ue *UnpackError
+	assert.ErrorAs(t, err, &ue)
+	assert.Equal(t, "IBM", ue.Name)
+	assert.Equal(t, [][]string{{"AAPL"}, {"IBM"}}, received)
+}
+
+func TestReadEvents(t *testing.T) {
+
+	r := strings.NewReader(`: keep-alive
+
+event: quote
+id: 1
+data: { "quotes": { "IBM": { "close": 140.5 },
+data:   "AAPL": { "close": 175.1 } } }
+
+data: {"quotes":{"IBM":{"close":140.75}}}
+
+event: ignored
+
+data: { "quotes": { "MSFT": { "close": 1 } } }
+`)
+
+	var (
+		events   []string
+		received []int
+	)
+	err := ReadEvents(context.Background(), r, quotef{}, func(event string, items []Unpackable) error {
+		events = append(events, event)
+		received = append(received, len(items))
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"quote", "message"}, events)
+	assert.Equal(t, []int{2, 1}, received)
+
+	err = ReadEvents(context.Background(), strings.NewReader("data: { \"quotes\": [] }\n\n"), quotef{}, func(event string, items []Unpackable) error {
+		return nil
+	})
+	assert.NotNil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = ReadEvents(ctx, strings.NewReader("data: {}\n\n"), quotef{}, func(event string, items []Unpackable) error {
+		return nil
+	})
+	assert.Equal(t, context.Canceled, err)
+
+	// Unpackables returned with errors are still passed to fn
+	received = nil
+	err = ReadEvents(context.Background(), strings.NewReader("data: { \"quotes\": { \"IBM\": { \"close\": \"x\" }, \"AAPL\": { \"close\": 1 } } }\n\n"), quotef{}, func(event string, items []Unpackable) error {
+		received = append(received, len(items))
+		return nil
+	}, WithContinueOnError())
+	var ue *UnpackError
+	assert.ErrorAs(t, err, &ue)
+	assert.Equal(t, []int{1}, received)
+}
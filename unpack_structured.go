@@ -0,0 +1,116 @@
+package unpack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// StructuredData holds the metadata and Unpackables of one group of the JSON
+// decoded by UnpackStructuredGroups
+type StructuredData[M any] struct {
+	Meta  M
+	Items []Unpackable
+}
+
+// UnpackStructuredGroups decodes JSON whose top level attributes matching the
+// pattern are groups (such as one per symbol in a multi-symbol response),
+// each holding its own metadata and data sections:
+//
+//	{
+//		"IBM": {
+//			"Meta Data": { "symbol": "IBM" },
+//			"Time Series (Daily)": { "2023-08-18": { ... }, ... }
+//		},
+//		...
+//	}
+//
+// The section named meta is decoded into the Meta of each group, unless meta
+// is "", and the section named data is unpacked into its Items using the
+// factory.  Top level attributes not matching the pattern are ignored, unless
+// WithSectionHandler, WithCaptureExtras or WithUnknownSections is used.  Of
+// the options, WithSkipSections, WithSectionHandler, WithUnknownSections,
+// WithCaptureExtras, WithErrorSections, WithMaxBytes and WithRecorder apply
+// once to the JSON object, and the others to the Unpackables of each group.
+// The groups are returned by name.  If a group's Unpackables are returned
+// together with an error (see WithContinueOnError), the group is retained,
+// and the errors of all the groups are returned together (as an Errors if
+// there is more than one); otherwise the error is returned identifying the
+// group.
+func UnpackStructuredGroups[M any, F UnpackableFactory](ctx context.Context, b []byte, pattern *regexp.Regexp, meta, data string, fact F, opts ...Option) (map[string]*StructuredData[M], error) {
+
+	o := newOptions(opts)
+
+	m, err := decodeSections(b, o)
+	if err != nil {
+		return nil, err
+	}
+
+	isGroup := func(name string) bool {
+		return pattern.MatchString(name) && !o.isSkipped(name)
+	}
+
+	errs, err := handleOthers(m, isGroup, o)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(m))
+	for name := range m {
+		if isGroup(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	ret := make(map[string]*StructuredData[M], len(names))
+	for _, name := range names {
+		sd, err := unpackStructured[M](ctx, m[name], meta, data, fact, opts)
+		if err != nil && sd == nil {
+			return nil, fmt.Errorf("group %q: %w", name, err)
+		}
+		errs = join(errs, err)
+
+		ret[name] = sd
+	}
+
+	return ret, errs
+}
+
+// unpackStructured decodes the metadata and Unpackables of one group for
+// UnpackStructuredGroups, returning nil if the error is not partial
+func unpackStructured[M any, F UnpackableFactory](ctx context.Context, raw json.RawMessage, meta, data string, fact F, opts []Option) (*StructuredData[M], error) {
+
+	o := newOptions(opts)
+
+	var g map[string]json.RawMessage
+	if err := o.codec.Unmarshal(raw, &g); err != nil {
+		return nil, err
+	}
+
+	sd := &StructuredData[M]{}
+
+	if meta != "" {
+		raw, ok := g[meta]
+		if !ok {
+			return nil, fmt.Errorf("section %q not found", meta)
+		}
+		if err := o.codec.Unmarshal(raw, &sd.Meta); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, ok := g[data]; !ok {
+		return nil, fmt.Errorf("section %q not found", data)
+	}
+
+	items, err := UnpackContext(ctx, raw, fact, append(opts[:len(opts):len(opts)], withinSection(data, g))...)
+	if err != nil && items == nil {
+		return nil, err
+	}
+
+	sd.Items = items
+	return sd, err
+}
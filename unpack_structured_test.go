@@ -0,0 +1,88 @@
+package unpack
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnpackStructuredGroups(t *testing.T) {
+
+	b := []byte(`
+{
+	"request": { "symbols": ["IBM", "AAPL"] },
+	"IBM": {
+		"Meta Data": { "symbol": "IBM", "refreshed": "2023-08-21" },
+		"Time Series (Daily)": { "2023-08-18": { "close": 140.5 }, "2023-08-21": { "close": 141.25 } }
+	},
+	"AAPL": {
+		"Meta Data": { "symbol": "AAPL", "refreshed": "2023-08-18" },
+		"Time Series (Daily)": { "2023-08-18": { "close": 175.1 } }
+	}
+}
+	`)
+
+	type meta struct {
+		Symbol    string `json:"symbol"`
+		Refreshed string `json:"refreshed"`
+	}
+
+	ctx := context.Background()
+	symbols := regexp.MustCompile(`^[A-Z]+$`)
+
+	groups, err := UnpackStructuredGroups[meta](ctx, b, symbols, "Meta Data", "Time Series (Daily)", quotef{}, WithOrdering(OrderingDescending))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(groups))
+	assert.Equal(t, meta{Symbol: "IBM", Refreshed: "2023-08-21"}, groups["IBM"].Meta)
+	assert.Equal(t, 2, len(groups["IBM"].Items))
+	assert.Equal(t, "2023-08-21", groups["IBM"].Items[0].(*quote).name)
+	assert.Equal(t, "AAPL", groups["AAPL"].Meta.Symbol)
+	assert.Equal(t, 1, len(groups["AAPL"].Items))
+
+	// Metadata is optional
+	groups, err = UnpackStructuredGroups[meta](ctx, b, regexp.MustCompile(`^IBM$`), "", "Time Series (Daily)", quotef{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(groups))
+	assert.Equal(t, "", groups["IBM"].Meta.Symbol)
+
+	_, err = UnpackStructuredGroups[meta](ctx, b, symbols, "Meta Data", "Time Series (Weekly)", quotef{})
+	assert.Equal(t, `group "AAPL": section "Time Series (Weekly)" not found`, err.Error())
+
+	// Options relating to the JSON object apply once
+	dir := t.TempDir()
+	rec, err := NewRecorder(dir, nil)
+	assert.Nil(t, err)
+	var extras map[string]json.RawMessage
+	groups, err = UnpackStructuredGroups[meta](ctx, b, symbols, "Meta Data", "Time Series (Daily)", quotef{},
+		WithRecorder(rec), WithCaptureExtras(func(m map[string]json.RawMessage) { extras = m }), WithMaxBytes(len(b)))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(groups))
+	assert.Equal(t, 1, len(extras))
+	assert.Equal(t, `{ "symbols": ["IBM", "AAPL"] }`, string(extras["request"]))
+	files, err := os.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(files))
+
+	var le *LimitError
+	_, err = UnpackStructuredGroups[meta](ctx, b, symbols, "Meta Data", "Time Series (Daily)", quotef{}, WithMaxBytes(len(b)-1))
+	assert.ErrorAs(t, err, &le)
+
+	// Groups returned with errors are retained, and the errors joined
+	b = []byte(`
+{
+	"IBM": { "Meta Data": {}, "data": { "2023-08-18": { "close": "x" }, "2023-08-21": { "close": 141.25 } } },
+	"AAPL": { "Meta Data": {}, "data": { "2023-08-18": { "close": "y" } } }
+}
+	`)
+	groups, err = UnpackStructuredGroups[meta](ctx, b, symbols, "Meta Data", "data", quotef{}, WithContinueOnError())
+	assert.Equal(t, 2, len(groups))
+	assert.Equal(t, 1, len(groups["IBM"].Items))
+	assert.Equal(t, 0, len(groups["AAPL"].Items))
+	var errs Errors
+	assert.ErrorAs(t, err, &errs)
+	assert.Equal(t, 2, len(errs))
+}
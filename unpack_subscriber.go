@@ -0,0 +1,96 @@
+package unpack
+
+import "context"
+
+// Subscriber unpacks a stream of frames, each a JSON object of the form
+// accepted by Unpack (for example, websocket messages each containing a
+// small named map), and delivers the Unpackables of each frame to Handler.
+// Frames are delivered in the order they are received, and Handler is never
+// called concurrently, even when frames are unpacked concurrently.
+type Subscriber[F UnpackableFactory] struct {
+	// Factory creates the Unpackables for each frame
+	Factory F
+	// Handler receives the Unpackables of each frame; an error stops Run
+	Handler func(ctx context.Context, items []Unpackable) error
+	// Options are applied when unpacking each frame
+	Options []Option
+	// Workers is the number of frames that may be unpacked concurrently;
+	// values less than 1 are treated as 1
+	Workers int
+}
+
+// Run consumes frames until the channel is closed, returning nil, or until
+// the context is done, a frame cannot be unpacked, or Handler returns an error,
+// returning the corresponding error.  If a frame's Unpackables are returned
+// together with an error (see WithContinueOnError), they are still passed to
+// Handler, and the errors of all such frames are returned by Run together
+// with any that stops it (as an Errors if there is more than one).  All goroutines started by Run have
+// finished unpacking, or will finish without blocking, when Run returns.
+func (s *Subscriber[F]) Run(ctx context.Context, frames <-chan []byte) error {
+
+	workers := s.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	type result struct {
+		items []Unpackable
+		err   error
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// pending holds the results of the frames being unpacked, in the order
+	// received, and its capacity limits the number unpacked concurrently
+	pending := make(chan chan result, workers)
+
+	go func() {
+		defer close(pending)
+		for {
+			select {
+			case <-runCtx.Done():
+				return
+			case b, ok := <-frames:
+				if !ok {
+					return
+				}
+
+				r := make(chan result, 1)
+				select {
+				case pending <- r:
+				case <-runCtx.Done():
+					return
+				}
+
+				go func() {
+					items, err := UnpackContext(runCtx, b, s.Factory, s.Options...)
+					r <- result{items: items, err: err}
+				}()
+			}
+		}
+	}()
+
+	var errs error
+
+	stop := func(err error) error {
+		cancel()
+		for range pending {
+		}
+		return join(errs, err)
+	}
+
+	for r := range pending {
+		res := <-r
+		if res.err != nil && res.items == nil {
+			return stop(res.err)
+		}
+		errs = join(errs, res.err)
+
+		if err := s.Handler(runCtx, res.items); err != nil {
+			return stop(err)
+		}
+	}
+
+	return join(errs, ctx.Err())
+}
@@ -0,0 +1,96 @@
+package unpack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscriber(t *testing.T) {
+
+	for _, workers := range []int{0, 4} {
+		frames := make(chan []byte)
+
+		var received []string
+		s := &Subscriber[quotef]{
+			Factory: quotef{},
+			Handler: func(ctx context.Context, items []Unpackable) error {
+				for _, item := range items {
+					received = append(received, item.(*quote).name)
+				}
+				return nil
+			},
+			Workers: workers,
+		}
+
+		go func() {
+			for i := 0; i < 20; i++ {
+				frames <- []byte(fmt.Sprintf(`{ "quotes": { "%02d-b": { "close": %d }, "%02d-a": { "close": %d } } }`, i, i, i, i))
+			}
+			close(frames)
+		}()
+
+		assert.Nil(t, s.Run(context.Background(), frames))
+
+		assert.Equal(t, 40, len(received))
+		for i := 0; i < 20; i++ {
+			assert.Equal(t, fmt.Sprintf("%02d-a", i), received[2*i])
+			assert.Equal(t, fmt.Sprintf("%02d-b", i), received[2*i+1])
+		}
+	}
+}
+
+func TestSubscriberErrors(t *testing.T) {
+
+	frames := make(chan []byte, 3)
+	frames <- []byte(`{ "quotes": { "a": { "close": 1 } } }`)
+	frames <- []byte(`{ "quotes": { "b": { "close": "x" } } }`)
+	frames <- []byte(`{ "quotes": { "c": { "close": 3 } } }`)
+
+	var received []string
+	s := &Subscriber[quotef]{
+		Factory: quotef{},
+		Handler: func(ctx context.Context, items []Unpackable) error {
+			received = append(received, items[0].(*quote).name)
+			return nil
+		},
+		Workers: 2,
+	}
+
+	var ue *UnpackError
+	assert.True(t, errors.As(s.Run(context.Background(), frames), &ue))
+	assert.Equal(t, "b", ue.Name)
+	assert.Equal(t, []string{"a"}, received)
+
+	stop := errors.New("stop")
+	s.Handler = func(ctx context.Context, items []Unpackable) error {
+		return stop
+	}
+	frames = make(chan []byte, 1)
+	frames <- []byte(`{ "quotes": { "a": { "close": 1 } } }`)
+	assert.Equal(t, stop, s.Run(context.Background(), frames))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, s.Run(ctx, make(chan []byte)))
+
+	// Unpackables returned with errors are still handled
+	received = nil
+	s.Handler = func(ctx context.Context, items []Unpackable) error {
+		for _, item := range items {
+			received = append(received, item.(*quote).name)
+		}
+		return nil
+	}
+	s.Options = []Option{WithContinueOnError()}
+	frames = make(chan []byte, 2)
+	frames <- []byte(`{ "quotes": { "a": { "close": 1 }, "b": { "close": "x" } } }`)
+	frames <- []byte(`{ "quotes": { "c": { "close": 3 } } }`)
+	close(frames)
+	assert.True(t, errors.As(s.Run(context.Background(), frames), &ue))
+	assert.Equal(t, "b", ue.Name)
+	assert.Equal(t, []string{"a", "c"}, received)
+}
@@ -1,7 +1,15 @@
 package unpack
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -104,3 +112,1017 @@ func TestUnpackAll(t *testing.T) {
 		}
 	}
 }
+
+type level int
+
+func (l *level) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "low":
+		*l = 1
+	case "high":
+		*l = 2
+	default:
+		return fmt.Errorf("unknown level %q", string(text))
+	}
+	return nil
+}
+
+type host struct {
+	name  string
+	IP    net.IP `json:"ip"`
+	Level level  `json:"level"`
+}
+
+func (h *host) SetName(name string) {
+	h.name = name
+}
+
+type hostf struct{}
+
+func (f hostf) New() Unpackable {
+	return new(host)
+}
+
+func TestUnpackTextUnmarshaler(t *testing.T) {
+
+	b := []byte(`
+{
+	"hosts": {
+		"gateway": { "ip": "10.0.0.1", "level": "high" }
+	}
+}
+	`)
+
+	u, err := Unpack(b, hostf{})
+	if err != nil {
+		t.Fatalf("Unexpected parse failure: %v", err)
+	}
+
+	assert.Equal(t, 1, len(u))
+
+	h := u[0].(*host)
+	assert.Equal(t, "gateway", h.name)
+	assert.Equal(t, "10.0.0.1", h.IP.String())
+	assert.Equal(t, level(2), h.Level)
+
+	_, err = Unpack([]byte(`{ "hosts": { "gateway": { "level": "medium" } } }`), hostf{})
+	assert.NotNil(t, err)
+}
+
+func TestUnpackOrderFrom(t *testing.T) {
+
+	b := []byte(`
+{
+	"order": ["US", "UK", "XX"],
+	"countries": {
+		"UK": {},
+		"FR": {},
+		"US": {},
+		"DE": {}
+	}
+}
+	`)
+
+	u, err := Unpack(b, ttf{}, WithOrderFrom("order"))
+	if err != nil {
+		t.Fatalf("Unexpected parse failure: %v", err)
+	}
+
+	names := []string{"US", "UK", "DE", "FR"}
+
+	assert.Equal(t, len(names), len(u))
+	for i, uu := range u {
+		assert.Equal(t, names[i], uu.(*tt).n)
+	}
+
+	_, err = Unpack(b, ttf{})
+	assert.NotNil(t, err)
+
+	_, err = Unpack(b, ttf{}, WithOrderFrom("sequence"))
+	assert.NotNil(t, err)
+}
+
+func TestUnpackOrderingDocument(t *testing.T) {
+
+	b := []byte(`
+{
+	"order": ["US"],
+	"countries": {
+		"UK": {},
+		"FR": {},
+		"US": {},
+		"DE": {},
+		"FR": {}
+	}
+}
+	`)
+
+	type tc struct {
+		opts  []Option
+		names []string
+	}
+
+	tests := []tc{
+		{
+			opts:  []Option{WithOrdering(OrderingDocument), WithSection("countries")},
+			names: []string{"UK", "FR", "US", "DE"},
+		},
+		{
+			opts:  []Option{WithOrdering(OrderingDocument), WithOrderFrom("order")},
+			names: []string{"US", "UK", "FR", "DE"},
+		},
+		{
+			opts:  []Option{WithOrdering(OrderingDocument), WithSection("countries"), WithDuplicateNamePolicy(DuplicateNameFirst), WithLimit(2)},
+			names: []string{"UK", "FR"},
+		},
+	}
+
+	for i, test := range tests {
+		u, err := Unpack(b, ttf{}, test.opts...)
+		assert.Nil(t, err, "test %d", i)
+
+		names := make([]string, len(u))
+		for j, uu := range u {
+			names[j] = uu.(*tt).n
+		}
+		assert.Equal(t, test.names, names, "test %d", i)
+	}
+
+	_, err := Unpack(b, ttf{}, WithOrdering(OrderingDocument), WithSection("countries"), WithDuplicateNamePolicy(DuplicateNameError))
+	assert.NotNil(t, err)
+}
+
+func TestUnpackLess(t *testing.T) {
+
+	b := []byte(`{ "versions": { "1.10.0": {}, "1.2.0": {}, "1.9.1": {}, "1.2": {} } }`)
+
+	version := func(s string) []int {
+		var v []int
+		for _, p := range strings.Split(s, ".") {
+			var n int
+			fmt.Sscan(p, &n)
+			v = append(v, n)
+		}
+		return v
+	}
+
+	// Compare the major and minor versions only
+	less := func(a, b string) bool {
+		va, vb := version(a), version(b)
+		if va[0] != vb[0] {
+			return va[0] < vb[0]
+		}
+		return va[1] < vb[1]
+	}
+
+	type tc struct {
+		opts  []Option
+		names []string
+	}
+
+	tests := []tc{
+		{
+			opts:  []Option{WithLess(less)},
+			names: []string{"1.2", "1.2.0", "1.9.1", "1.10.0"},
+		},
+		{
+			opts:  []Option{WithLess(less), WithOrdering(OrderingDescending)},
+			names: []string{"1.2.0", "1.2", "1.9.1", "1.10.0"},
+		},
+		{
+			opts:  []Option{WithLess(less), WithOrdering(OrderingDocument), WithLimit(3)},
+			names: []string{"1.2.0", "1.2", "1.9.1"},
+		},
+	}
+
+	for i, test := range tests {
+		u, err := Unpack(b, ttf{}, test.opts...)
+		assert.Nil(t, err, "test %d", i)
+
+		names := make([]string, len(u))
+		for j, uu := range u {
+			names[j] = uu.(*tt).n
+		}
+		assert.Equal(t, test.names, names, "test %d", i)
+	}
+}
+
+func TestUnpackSortBy(t *testing.T) {
+
+	b := []byte(`
+{
+	"cities": {
+		"London": { "population": 9000000 },
+		"Lyon": { "population": 520000 },
+		"Manchester": { "population": 550000 },
+		"Paris": { "population": 2100000 },
+		"Bad": { "population": "many" }
+	}
+}
+	`)
+
+	byPopulation := WithSortBy(func(a, b *city) bool { return a.Population < b.Population })
+
+	u, err := Unpack(b, cityf{}, byPopulation, WithContinueOnError())
+	assert.NotNil(t, err)
+
+	names := make([]string, len(u))
+	for i, uu := range u {
+		names[i] = uu.(*city).name
+	}
+	assert.Equal(t, []string{"Lyon", "Manchester", "Paris", "London"}, names)
+
+	u, err = Unpack(b, cityf{}, byPopulation, WithKeys("Paris", "Lyon"))
+	assert.Nil(t, err)
+	assert.Equal(t, "Lyon", u[0].(*city).name)
+}
+
+type countingCodec struct {
+	StdCodec
+	unmarshals int
+}
+
+func (c *countingCodec) Unmarshal(data []byte, v interface{}) error {
+	c.unmarshals++
+	return c.StdCodec.Unmarshal(data, v)
+}
+
+func TestUnpackWithCodec(t *testing.T) {
+
+	b := []byte(`
+{
+	"a": {
+		"x": {},
+		"y": {}
+	}
+}
+	`)
+
+	c := &countingCodec{}
+
+	u, err := Unpack(b, ttf{}, WithCodec(c))
+	if err != nil {
+		t.Fatalf("Unexpected parse failure: %v", err)
+	}
+
+	assert.Equal(t, 2, len(u))
+	assert.Equal(t, 4, c.unmarshals)
+}
+
+func TestUnpackCheckpoint(t *testing.T) {
+
+	b := []byte(`
+{
+	"a": {
+		"p": {},
+		"q": {},
+		"r": {},
+		"s": {},
+		"t": {}
+	}
+}
+	`)
+
+	var checkpoints []string
+	checkpoint := func(name string) error {
+		checkpoints = append(checkpoints, name)
+		return nil
+	}
+
+	u, err := Unpack(b, ttf{}, WithCheckpoint(2, checkpoint))
+	if err != nil {
+		t.Fatalf("Unexpected parse failure: %v", err)
+	}
+	assert.Equal(t, 5, len(u))
+	assert.Equal(t, []string{"q", "s", "t"}, checkpoints)
+
+	checkpoints = nil
+	u, err = Unpack(b, ttf{}, WithCheckpoint(2, checkpoint), WithResumeAfter("q"))
+	if err != nil {
+		t.Fatalf("Unexpected parse failure: %v", err)
+	}
+	assert.Equal(t, 3, len(u))
+	assert.Equal(t, "r", u[0].(*tt).n)
+	assert.Equal(t, []string{"s", "t"}, checkpoints)
+
+	stop := errors.New("stop")
+	_, err = Unpack(b, ttf{}, WithCheckpoint(1, func(name string) error { return stop }))
+	assert.Equal(t, stop, err)
+
+	_, err = Unpack(b, ttf{}, WithResumeAfter("z"))
+	assert.NotNil(t, err)
+
+	u, err = Unpack(b, ttf{}, WithCheckpoint(2, checkpoint), WithResumeAfter("t"))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(u))
+}
+
+func TestUnpackParallel(t *testing.T) {
+
+	var sb strings.Builder
+	sb.WriteString(`{ "hosts": {`)
+	for i := 0; i < 500; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		fmt.Fprintf(&sb, `"h%03d": { "ip": "10.0.%d.%d", "level": "low" }`, i, i/256, i%256)
+	}
+	sb.WriteString(`} }`)
+	b := []byte(sb.String())
+
+	seq, err := Unpack(b, hostf{})
+	if err != nil {
+		t.Fatalf("Unexpected parse failure: %v", err)
+	}
+
+	par, err := Unpack(b, hostf{}, WithParallelism(8))
+	if err != nil {
+		t.Fatalf("Unexpected parse failure: %v", err)
+	}
+
+	assert.Equal(t, seq, par)
+
+	b = []byte(strings.Replace(strings.Replace(sb.String(), `"h100": { "ip": "10.0.0.100", "level": "low" }`, `"h100": { "level": "medium" }`, 1),
+		`"h400": { "ip": "10.0.1.144", "level": "low" }`, `"h400": { "level": "none" }`, 1))
+
+	_, seqErr := Unpack(b, hostf{})
+	_, parErr := Unpack(b, hostf{}, WithParallelism(8))
+	assert.NotNil(t, parErr)
+	assert.Equal(t, seqErr, parErr)
+}
+
+func TestUnpackContext(t *testing.T) {
+
+	b := []byte(`
+{
+	"a": {
+		"x": {},
+		"y": {}
+	}
+}
+	`)
+
+	u, err := UnpackContext(context.Background(), b, ttf{})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(u))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = UnpackContext(ctx, b, ttf{})
+	assert.Equal(t, context.Canceled, err)
+
+	_, err = UnpackContext(ctx, b, ttf{}, WithParallelism(2))
+	assert.Equal(t, context.Canceled, err)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+
+	_, err = UnpackContext(ctx, b, ttf{}, WithCheckpoint(1, func(name string) error {
+		cancel()
+		return nil
+	}))
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestUnpackProgress(t *testing.T) {
+
+	b := []byte(`
+{
+	"a": {
+		"x": {},
+		"y": {},
+		"z": {}
+	}
+}
+	`)
+
+	for _, n := range []int{1, 3} {
+		var done []int
+		u, err := Unpack(b, ttf{}, WithParallelism(n), WithProgress(func(d, total int) {
+			assert.Equal(t, 3, total)
+			done = append(done, d)
+		}))
+		assert.Nil(t, err)
+		assert.Equal(t, 3, len(u))
+		assert.Equal(t, []int{1, 2, 3}, done)
+	}
+}
+
+func TestUnpackLimits(t *testing.T) {
+
+	b := []byte(`
+{
+	"a": {
+		"x": {},
+		"y": {},
+		"z": {}
+	}
+}
+	`)
+
+	var le *LimitError
+
+	_, err := Unpack(b, ttf{}, WithMaxItems(2))
+	assert.True(t, errors.As(err, &le))
+	assert.Equal(t, &LimitError{Limit: "items", Max: 2, Actual: 3}, le)
+
+	_, err = Unpack(b, ttf{}, WithMaxBytes(10))
+	assert.True(t, errors.As(err, &le))
+	assert.Equal(t, "bytes", le.Limit)
+	assert.Equal(t, len(b), le.Actual)
+
+	u, err := Unpack(b, ttf{}, WithMaxItems(3), WithMaxBytes(len(b)))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(u))
+
+	// The count stops once the limit is exceeded, and duplicates count once
+	_, err = Unpack([]byte(`{ "a": { "x": {}, "y": {}, "z": {}, "w": {} } }`), ttf{}, WithMaxItems(1))
+	assert.True(t, errors.As(err, &le))
+	assert.Equal(t, &LimitError{Limit: "items", Max: 1, Actual: 2}, le)
+
+	u, err = Unpack([]byte(`{ "a": { "x": {}, "y": {}, "x": {} } }`), ttf{}, WithMaxItems(2))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(u))
+}
+
+type city struct {
+	name       string
+	Capital    bool `json:"capital"`
+	Population int  `json:"population"`
+}
+
+func (c *city) SetName(name string) {
+	c.name = name
+}
+
+type cityf struct {
+	capitals map[string]bool
+}
+
+func (f cityf) New() Unpackable {
+	return new(city)
+}
+
+func (f cityf) NewNamed(name string) Unpackable {
+	return &city{Capital: f.capitals[name]}
+}
+
+func TestUnpackNamedFactory(t *testing.T) {
+
+	b := []byte(`
+{
+	"cities": {
+		"London": { "population": 9000000 },
+		"Manchester": { "population": 550000 },
+		"Paris": { "population": 2100000, "capital": false }
+	}
+}
+	`)
+
+	u, err := Unpack(b, cityf{capitals: map[string]bool{"London": true, "Paris": true}})
+	if err != nil {
+		t.Fatalf("Unexpected parse failure: %v", err)
+	}
+
+	assert.Equal(t, 3, len(u))
+	assert.Equal(t, &city{name: "London", Capital: true, Population: 9000000}, u[0])
+	assert.Equal(t, &city{name: "Manchester", Capital: false, Population: 550000}, u[1])
+	assert.Equal(t, &city{name: "Paris", Capital: false, Population: 2100000}, u[2])
+}
+
+func TestUnpackInitFn(t *testing.T) {
+
+	b := []byte(`
+{
+	"history": {
+		"2023-08-18": { "close": 140.5 },
+		"2023-08-21": { "close": 141.25 }
+	}
+}
+	`)
+
+	init := func(name string, u Unpackable) error {
+		q := u.(*quote)
+		assert.Equal(t, name, q.name)
+
+		d, err := time.Parse("2006-01-02", name)
+		q.Date = d
+		return err
+	}
+
+	u, err := Unpack(b, quotef{}, WithInitFn(init))
+	if err != nil {
+		t.Fatalf("Unexpected parse failure: %v", err)
+	}
+
+	assert.Equal(t, 2, len(u))
+	assert.Equal(t, time.Date(2023, 8, 18, 0, 0, 0, 0, time.UTC), u[0].(*quote).Date)
+	assert.Equal(t, time.Date(2023, 8, 21, 0, 0, 0, 0, time.UTC), u[1].(*quote).Date)
+
+	_, err = Unpack([]byte(`{ "history": { "latest": { "close": 1 } } }`), quotef{}, WithInitFn(init))
+	assert.NotNil(t, err)
+}
+
+func TestDepth(t *testing.T) {
+
+	type tc struct {
+		json  string
+		depth int
+	}
+
+	tests := []tc{
+		{json: `1`, depth: 0},
+		{json: `{}`, depth: 1},
+		{json: `{ "a": [1, 2, { "b": {} }] }`, depth: 4},
+		{json: `{ "a": "[[[{{{", "b": "\"[" }`, depth: 1},
+		{json: `[[[]], [[[]]]]`, depth: 4},
+	}
+
+	for i, test := range tests {
+		assert.Equal(t, test.depth, depth([]byte(test.json)), "test %d", i)
+	}
+}
+
+func TestUnpackMaxDepth(t *testing.T) {
+
+	b := []byte(`
+{
+	"a": {
+		"x": { "n": [[1]] },
+		"y": { "n": "[[[[" }
+	}
+}
+	`)
+
+	_, err := Unpack(b, ttf{}, WithMaxDepth(2))
+
+	var le *LimitError
+	assert.True(t, errors.As(err, &le))
+	assert.Equal(t, &LimitError{Limit: "depth", Max: 2, Actual: 3}, le)
+
+	u, err := Unpack(b, ttf{}, WithMaxDepth(3))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(u))
+}
+
+type noStrictCodec struct {
+	StdCodec
+}
+
+func (noStrictCodec) NewDecoder(r io.Reader) Decoder {
+	return struct{ Decoder }{json.NewDecoder(r)}
+}
+
+func TestUnpackStrictFields(t *testing.T) {
+
+	b := []byte(`
+{
+	"cities": {
+		"London": { "population": 9000000, "capital": true },
+		"Paris": { "population": 2100000, "mayor": "Anne Hidalgo" }
+	}
+}
+	`)
+
+	u, err := Unpack(b, cityf{})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(u))
+
+	_, err = Unpack(b, cityf{}, WithStrictFields())
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), `"Paris"`)
+	assert.Contains(t, err.Error(), `"mayor"`)
+
+	_, err = Unpack(b, cityf{}, WithStrictFields(), WithCodec(noStrictCodec{}))
+	assert.NotNil(t, err)
+
+	_, err = Unpack([]byte(`{ "history": { "a": { "date": "2023-08-18", "open": 1 } } }`), quotef{}, WithStrictFields())
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), `"open"`)
+}
+
+func TestUnpackNameCollisions(t *testing.T) {
+
+	b := []byte(`
+{
+	"cities": {
+		"London": { "population": 9000000 },
+		"london ": { "capital": true },
+		"Paris": { "population": 2100000 }
+	}
+}
+	`)
+
+	u, err := Unpack(b, cityf{}, WithNameCollisionPolicy(NameCollisionKeep))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(u))
+
+	_, err = Unpack(b, cityf{}, WithNameCollisionPolicy(NameCollisionError))
+	assert.NotNil(t, err)
+
+	u, err = Unpack(b, cityf{}, WithNameCollisionPolicy(NameCollisionMerge))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(u))
+	assert.Equal(t, &city{name: "London", Capital: true, Population: 9000000}, u[0])
+	assert.Equal(t, &city{name: "Paris", Population: 2100000}, u[1])
+}
+
+func TestUnpackNameTransform(t *testing.T) {
+
+	b := []byte(`
+{
+	"cities": {
+		"  UK-London ": { "population": 9000000 },
+		"uk-london": { "population": 8900000 },
+		"FR-Paris": { "population": 2100000 }
+	}
+}
+	`)
+
+	nameOf := func(u Unpackable) string {
+		return u.(*city).name
+	}
+
+	strip := WithNameTransform(func(name string) string {
+		_, after, _ := strings.Cut(name, "-")
+		return after
+	})
+	fact := cityf{capitals: map[string]bool{"paris": true}}
+
+	u, err := Unpack(b, fact, WithNameNormalization(NameTrimSpace|NameLowerCase), strip)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(u))
+	assert.Equal(t, []string{"london", "paris", "london"}, []string{nameOf(u[0]), nameOf(u[1]), nameOf(u[2])})
+	assert.True(t, u[1].(*city).Capital)
+
+	_, err = Unpack(b, fact, WithNameNormalization(NameTrimSpace|NameLowerCase), strip, WithNameCollisionPolicy(NameCollisionError))
+	assert.NotNil(t, err)
+
+	// Without normalization, the transformed names do not collide
+	u, err = Unpack(b, fact, strip, WithNameCollisionPolicy(NameCollisionError))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"London ", "Paris", "london"}, []string{nameOf(u[0]), nameOf(u[1]), nameOf(u[2])})
+
+	var names []string
+	u, err = Unpack(b, fact, WithNameNormalization(NameLowerCase), WithNameCollisionPolicy(NameCollisionMerge),
+		WithInitFn(func(name string, u Unpackable) error {
+			names = append(names, name)
+			return nil
+		}))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(u))
+	assert.Equal(t, []string{"  uk-london ", "fr-paris", "uk-london"}, names)
+}
+
+type reading struct {
+	name     string
+	original string
+	Value    float64 `json:"value"`
+}
+
+func (r *reading) SetName(name string) {
+	r.name = name
+}
+
+func (r *reading) SetOriginalName(name string) {
+	r.original = name
+}
+
+type readingf struct{}
+
+func (f readingf) New() Unpackable {
+	return new(reading)
+}
+
+func TestUnpackKeyTimeZone(t *testing.T) {
+
+	b := []byte(`
+{
+	"readings": {
+		"2023-08-18 20:00": { "value": 1.5 },
+		"2023-08-18 21:30": { "value": 2.5 }
+	}
+}
+	`)
+
+	eastern := time.FixedZone("EDT", -4*60*60)
+
+	u, err := Unpack(b, readingf{}, WithKeyTimeZone("2006-01-02 15:04", eastern, nil))
+	assert.Nil(t, err)
+	assert.Equal(t, &reading{name: "2023-08-19 00:00", original: "2023-08-18 20:00", Value: 1.5}, u[0])
+	assert.Equal(t, &reading{name: "2023-08-19 01:30", original: "2023-08-18 21:30", Value: 2.5}, u[1])
+
+	u, err = Unpack(b, readingf{}, WithKeyTimeZone("2006-01-02 15:04", nil, eastern))
+	assert.Nil(t, err)
+	assert.Equal(t, "2023-08-18 16:00", u[0].(*reading).name)
+
+	u, err = Unpack(b, readingf{})
+	assert.Nil(t, err)
+	assert.Equal(t, &reading{name: "2023-08-18 20:00", original: "2023-08-18 20:00", Value: 1.5}, u[0])
+
+	_, err = Unpack(b, readingf{}, WithKeyTimeZone("2006-01-02", eastern, nil))
+	var ue *UnpackError
+	assert.ErrorAs(t, err, &ue)
+	assert.Equal(t, "2023-08-18 20:00", ue.Name)
+}
+
+func TestUnpackIfNewer(t *testing.T) {
+
+	b := []byte(`
+{
+	"Meta Data": { "2. Symbol": "IBM", "3. Last Refreshed": "2023-08-21" },
+	"Time Series (Daily)": {
+		"2023-08-18": { "close": 140.5 },
+		"2023-08-21": { "close": 141.25 }
+	}
+}
+	`)
+
+	ctx := context.Background()
+
+	u, refreshed, err := UnpackIfNewer(ctx, b, quotef{}, "Meta Data", "3. Last Refreshed", "2023-08-18")
+	assert.Nil(t, err)
+	assert.Equal(t, "2023-08-21", refreshed)
+	assert.Equal(t, 2, len(u))
+
+	u, refreshed, err = UnpackIfNewer(ctx, b, quotef{}, "Meta Data", "3. Last Refreshed", "2023-08-21")
+	assert.Equal(t, ErrNotModified, err)
+	assert.Equal(t, "2023-08-21", refreshed)
+	assert.Nil(t, u)
+
+	_, _, err = UnpackIfNewer(ctx, b, quotef{}, "Meta Data", "4. Interval", "")
+	assert.NotNil(t, err)
+
+	_, _, err = UnpackIfNewer(ctx, b, quotef{}, "Information", "3. Last Refreshed", "")
+	assert.NotNil(t, err)
+}
+
+type listing struct {
+	name    string
+	Price   float64 `json:"price"`
+	Deleted bool    `json:"deleted" unpack:",deleted"`
+}
+
+func (l *listing) SetName(name string) {
+	l.name = name
+}
+
+type listingf struct{}
+
+func (f listingf) New() Unpackable {
+	return new(listing)
+}
+
+func TestUnpackTombstones(t *testing.T) {
+
+	b := []byte(`
+{
+	"listings": {
+		"a": { "price": 10 },
+		"b": null,
+		"c": { "price": 12, "deleted": true },
+		"d": { "price": 13, "deleted": false }
+	}
+}
+	`)
+
+	u, err := Unpack(b, listingf{})
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(u))
+
+	for _, opts := range [][]Option{nil, {WithParallelism(4)}} {
+		var deleted []string
+		u, err = Unpack(b, listingf{}, append(opts, WithTombstones(func(name string) {
+			deleted = append(deleted, name)
+		}))...)
+		assert.Nil(t, err)
+		assert.Equal(t, []Unpackable{&listing{name: "a", Price: 10}, &listing{name: "d", Price: 13}}, u)
+		assert.Equal(t, []string{"b", "c"}, deleted)
+	}
+
+	u, err = Unpack(b, listingf{}, WithTombstones(nil), WithOrdering(OrderingDescending))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(u))
+}
+
+func TestUnpackDuplicateNames(t *testing.T) {
+
+	b := []byte(`
+{
+	"cities": {
+		"London": { "population": 8000000 },
+		"Paris": { "population": 2100000 },
+		"London": { "population": 9000000 }
+	}
+}
+	`)
+
+	type tc struct {
+		policy     DuplicateNamePolicy
+		population int
+		parseable  bool
+	}
+
+	tests := []tc{
+		{policy: DuplicateNameLast, population: 9000000, parseable: true},
+		{policy: DuplicateNameFirst, population: 8000000, parseable: true},
+		{policy: DuplicateNameError, parseable: false},
+	}
+
+	for i, test := range tests {
+		u, err := Unpack(b, cityf{}, WithDuplicateNamePolicy(test.policy))
+		if !test.parseable {
+			assert.NotNil(t, err, "test %d", i)
+			continue
+		}
+		assert.Nil(t, err, "test %d", i)
+		assert.Equal(t, 2, len(u), "test %d", i)
+		assert.Equal(t, test.population, u[0].(*city).Population, "test %d", i)
+	}
+
+	for _, j := range []string{`{ "a": null }`, `{ "a": {} }`} {
+		u, err := Unpack([]byte(j), ttf{}, WithDuplicateNamePolicy(DuplicateNameError))
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(u))
+	}
+
+	_, err := Unpack([]byte(`{ "a": [] }`), ttf{}, WithDuplicateNamePolicy(DuplicateNameError))
+	assert.NotNil(t, err)
+}
+
+func TestUnpackMaxDecodeDuration(t *testing.T) {
+
+	b := []byte(`{ "a": { "a": {}, "b": {}, "c": {}, "d": {}, "e": {}, "f": {}, "g": {}, "h": {} } }`)
+
+	slow := WithInitFn(func(name string, u Unpackable) error {
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	})
+
+	u, err := Unpack(b, ttf{}, slow, WithMaxDecodeDuration(25*time.Millisecond))
+	assert.Equal(t, ErrDecodeDurationExceeded, err)
+	assert.Nil(t, u)
+
+	u, err = Unpack(b, ttf{}, slow, WithMaxDecodeDuration(time.Second))
+	assert.Nil(t, err)
+	assert.Equal(t, 8, len(u))
+
+	for _, parallelism := range []int{1, 3} {
+		var checkpoints []string
+
+		u, err = Unpack(b, ttf{}, slow, WithMaxDecodeDuration(15*time.Millisecond), WithContinueOnError(),
+			WithParallelism(parallelism), WithCheckpoint(100, func(name string) error {
+				checkpoints = append(checkpoints, name)
+				return nil
+			}))
+		assert.ErrorIs(t, err, ErrDecodeDurationExceeded, "parallelism %d", parallelism)
+		assert.True(t, len(u) > 0 && len(u) < 8, "parallelism %d", parallelism)
+
+		// The Unpackables returned are those before the first not populated
+		for i, uu := range u {
+			assert.Equal(t, string(rune('a'+i)), uu.(*tt).n, "parallelism %d", parallelism)
+		}
+		assert.Equal(t, []string{u[len(u)-1].(*tt).n}, checkpoints, "parallelism %d", parallelism)
+	}
+}
+
+func TestUnpackContinueOnError(t *testing.T) {
+
+	b := []byte(`
+{
+	"hosts": {
+		"a": { "ip": "10.0.0.1", "level": "high" },
+		"b": { "level": "medium" },
+		"c": { "ip": "10.0.0.3" },
+		"d": { "ip": [] }
+	}
+}
+	`)
+
+	_, err := Unpack(b, hostf{})
+	assert.NotNil(t, err)
+
+	for _, n := range []int{1, 4} {
+		u, err := Unpack(b, hostf{}, WithContinueOnError(), WithParallelism(n))
+
+		assert.Equal(t, 2, len(u))
+		assert.Equal(t, "a", u[0].(*host).name)
+		assert.Equal(t, "c", u[1].(*host).name)
+
+		var errs Errors
+		assert.True(t, errors.As(err, &errs))
+		assert.Equal(t, 2, len(errs))
+		assert.Contains(t, errs[0].Error(), `"b"`)
+		assert.Contains(t, errs[1].Error(), `"d"`)
+	}
+
+	u, err := Unpack([]byte(`{ "hosts": { "a": {} } }`), hostf{}, WithContinueOnError())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(u))
+}
+
+func TestUnpackErrorHandler(t *testing.T) {
+
+	b := []byte(`
+{
+	"hosts": {
+		"a": { "ip": "10.0.0.1", "level": "high" },
+		"b": { "level": "medium" },
+		"c": { "ip": "10.0.0.3" }
+	}
+}
+	`)
+
+	var handled []string
+	skip := func(name string, raw json.RawMessage, err error) error {
+		handled = append(handled, name, string(raw))
+		return nil
+	}
+
+	u, err := Unpack(b, hostf{}, WithErrorHandler(skip))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(u))
+	assert.Equal(t, "c", u[1].(*host).name)
+	assert.Equal(t, []string{"b", `{ "level": "medium" }`}, handled)
+
+	bad := errors.New("bad item")
+	fail := func(name string, raw json.RawMessage, err error) error {
+		return bad
+	}
+
+	_, err = Unpack(b, hostf{}, WithErrorHandler(fail))
+	assert.Equal(t, bad, err)
+
+	u, err = Unpack(b, hostf{}, WithErrorHandler(fail), WithContinueOnError())
+	assert.Equal(t, 2, len(u))
+	assert.Equal(t, Errors{bad}, err)
+}
+
+type country struct {
+	name       string
+	Capital    string         `json:"capital"`
+	Population map[string]int `json:"population"`
+}
+
+func (c *country) SetName(name string) {
+	c.name = name
+}
+
+type countryf struct{}
+
+func (f countryf) New() Unpackable {
+	return new(country)
+}
+
+func TestUnpackError(t *testing.T) {
+
+	type tc struct {
+		json string
+		opts []Option
+		name string
+		path string
+	}
+
+	tests := []tc{
+		{
+			json: `{ "countries": { "UK": { "capital": "London", "population": { "2023": "many" } } } }`,
+			name: "UK",
+			path: "population.2023",
+		},
+		{
+			json: `{ "countries": { "UK": { "capital": 1 } } }`,
+			name: "UK",
+			path: "capital",
+		},
+		{
+			json: `{ "countries": { "UK": { "capital": "London", "king": "Charles" } } }`,
+			opts: []Option{WithStrictFields()},
+			name: "UK",
+			path: "king",
+		},
+		{
+			json: `{ "countries": { "UK": { "population": [[1]] } } }`,
+			opts: []Option{WithMaxDepth(2)},
+			name: "UK",
+		},
+	}
+
+	for i, test := range tests {
+		_, err := Unpack([]byte(test.json), countryf{}, test.opts...)
+
+		var ue *UnpackError
+		if !errors.As(err, &ue) {
+			t.Fatalf("Expected UnpackError for test %d: %v", i, err)
+		}
+		assert.Equal(t, test.name, ue.Name, "test %d", i)
+		assert.Equal(t, test.path, ue.Path, "test %d", i)
+		assert.NotNil(t, ue.Err, "test %d", i)
+	}
+
+	_, err := Unpack([]byte(`{ "history": { "a": { "date": "18/08/2023" } } }`), quotef{})
+
+	var ue *UnpackError
+	assert.True(t, errors.As(err, &ue))
+	assert.Equal(t, &UnpackError{Name: "a", Path: "date", Err: ue.Err}, ue)
+}
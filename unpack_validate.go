@@ -1,24 +1,116 @@
 package unpack
 
-import valid "github.com/asaskevich/govalidator"
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	valid "github.com/asaskevich/govalidator"
+)
 
 // UnpackAndValidate returns a validated set of Unpackables, where the
 // validation to be performed is defined in the tag of each attribute
 // see: https://pkg.go.dev/github.com/asaskevich/govalidator?utm_source=godoc
-func UnpackAndValidate(b []byte, fact UnpackableFactory) ([]Unpackable, error) {
+// If Unpackables are returned by Unpack together with an error (see
+// WithContinueOnError), they are still validated.  With WithContinueOnError,
+// an Unpackable that is not valid is omitted, and its error is returned
+// together with any others in an Errors, rather than stopping.
+func UnpackAndValidate(b []byte, fact UnpackableFactory, opts ...Option) ([]Unpackable, error) {
 
-	unpackables, err := Unpack(b, fact)
-	if err != nil {
+	unpackables, err := Unpack(b, fact, opts...)
+	if err != nil && unpackables == nil {
 		return nil, err
 	}
 
+	continueOnError := newOptions(opts).continueOnError
+
 	// Validate
+	validated := make([]Unpackable, 0, len(unpackables))
 	for _, unpackable := range unpackables {
-		_, err = valid.ValidateStruct(unpackable)
+		if _, verr := valid.ValidateStruct(unpackable); verr != nil {
+			if !continueOnError {
+				return nil, verr
+			}
+			err = join(err, verr)
+			continue
+		}
+		validated = append(validated, unpackable)
+	}
+
+	return validated, err
+}
+
+// Reference declares that the Attribute of every item in Section must
+// hold the name of an item in the Target section of the same JSON object
+type Reference struct {
+	Section   string
+	Attribute string
+	Target    string
+}
+
+// ValidateReferences checks the references between sections of the JSON object,
+// for JSON that bundles related lookup tables into a single object:
+//
+//	{
+//		"cities": {
+//			"London": { "country_code": "UK" }
+//		},
+//		"countries": {
+//			"UK": { ... }
+//		}
+//	}
+//
+// would be validated using Reference{Section: "cities", Attribute: "country_code", Target: "countries"}
+func ValidateReferences(b []byte, refs ...Reference) error {
+
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+
+	sections := map[string]map[string]map[string]json.RawMessage{}
+	section := func(name string) (map[string]map[string]json.RawMessage, error) {
+		if s, ok := sections[name]; ok {
+			return s, nil
+		}
+		raw, ok := m[name]
+		if !ok {
+			return nil, fmt.Errorf("section %q not found", name)
+		}
+		var s map[string]map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, fmt.Errorf("section %q: %w", name, err)
+		}
+		sections[name] = s
+		return s, nil
+	}
+
+	for _, ref := range refs {
+		src, err := section(ref.Section)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		target, err := section(ref.Target)
+		if err != nil {
+			return err
+		}
+
+		names := make([]string, 0, len(src))
+		for name := range src {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			var key string
+			if err := json.Unmarshal(src[name][ref.Attribute], &key); err != nil {
+				return fmt.Errorf("%s %q: attribute %q is not a valid reference", ref.Section, name, ref.Attribute)
+			}
+			if _, ok := target[key]; !ok {
+				return fmt.Errorf("%s %q: %q not found in %s", ref.Section, name, key, ref.Target)
+			}
 		}
 	}
 
-	return unpackables, nil
+	return nil
 }
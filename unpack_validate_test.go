@@ -0,0 +1,129 @@
+package unpack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateReferences(t *testing.T) {
+
+	type tc struct {
+		json  string
+		valid bool
+	}
+
+	refs := []Reference{
+		{Section: "cities", Attribute: "country_code", Target: "countries"},
+	}
+
+	tests := []tc{
+		{
+			json: `
+{
+	"cities": {
+		"London": { "country_code": "UK" },
+		"Paris": { "country_code": "FR" }
+	},
+	"countries": {
+		"UK": {},
+		"FR": {}
+	}
+}
+			`,
+			valid: true,
+		},
+		{
+			json: `
+{
+	"cities": {
+		"London": { "country_code": "UK" },
+		"Paris": { "country_code": "FR" }
+	},
+	"countries": {
+		"UK": {}
+	}
+}
+			`,
+			valid: false,
+		},
+		{
+			json: `
+{
+	"cities": {
+		"London": {}
+	},
+	"countries": {
+		"UK": {}
+	}
+}
+			`,
+			valid: false,
+		},
+		{
+			json: `
+{
+	"cities": {
+		"London": { "country_code": "UK" }
+	}
+}
+			`,
+			valid: false,
+		},
+	}
+
+	for i, test := range tests {
+		err := ValidateReferences([]byte(test.json), refs...)
+		if test.valid {
+			assert.Nil(t, err, "test %d", i)
+		} else {
+			assert.NotNil(t, err, "test %d", i)
+		}
+	}
+}
+
+type contact struct {
+	Name  string
+	Email string `json:"email" valid:"email"`
+}
+
+func (c *contact) SetName(name string) {
+	c.Name = name
+}
+
+type contactf struct{}
+
+func (contactf) New() Unpackable {
+	return new(contact)
+}
+
+func TestUnpackAndValidate(t *testing.T) {
+
+	b := []byte(`
+{
+	"contacts": {
+		"alice": { "email": "alice@example.com" },
+		"bob": { "email": "not an address" },
+		"carol": { "email": 1 }
+	}
+}
+	`)
+
+	_, err := UnpackAndValidate(b, contactf{})
+	assert.NotNil(t, err)
+
+	_, err = UnpackAndValidate(b, contactf{}, WithKeys("alice", "bob"))
+	assert.NotNil(t, err)
+
+	u, err := UnpackAndValidate(b, contactf{}, WithKeys("alice"))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(u))
+
+	// Unpackables returned with errors are validated, and the errors joined
+	u, err = UnpackAndValidate(b, contactf{}, WithContinueOnError())
+	assert.Equal(t, 1, len(u))
+	assert.Equal(t, "alice", u[0].(*contact).Name)
+	var errs Errors
+	assert.ErrorAs(t, err, &errs)
+	assert.Equal(t, 2, len(errs))
+}
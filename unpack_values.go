@@ -0,0 +1,122 @@
+package unpack
+
+import (
+	"bytes"
+	"encoding/json"
+	"unicode/utf8"
+)
+
+// limitValues applies the maximum lengths of WithMaxValueLength and
+// WithFieldMaxValueLength to the string values within the JSON object,
+// either truncating them or returning a *LimitError identifying the
+// top level attribute containing the string
+func limitValues(b []byte, o *options) ([]byte, error) {
+
+	var (
+		out   []byte // only allocated once a string is truncated
+		from  int    // start of the bytes of b not yet copied to out
+		d     int
+		attr  string
+		start = -1 // start of the content of the current string
+	)
+
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+
+		if start >= 0 {
+			switch c {
+			case '\\':
+				i++
+			case '"':
+				content := b[start:i]
+				start = -1
+
+				if isKey(b[i+1:]) {
+					if d == 1 {
+						attr = unquote(content)
+					}
+					continue
+				}
+
+				max := o.maxValueLength
+				if n, ok := o.fieldValueLengths[attr]; ok {
+					max = n
+				}
+				if max <= 0 || len(content) <= max {
+					continue
+				}
+
+				if !o.truncateValues {
+					return nil, &UnpackError{Path: attr, Err: &LimitError{Limit: "value length", Max: max, Actual: len(content)}}
+				}
+
+				out = append(out, b[from:i-len(content)]...)
+				out = append(out, truncate(content, max)...)
+				from = i
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			start = i + 1
+		case '{', '[':
+			d++
+		case '}', ']':
+			d--
+		}
+	}
+
+	if out == nil {
+		return b, nil
+	}
+	return append(out, b[from:]...), nil
+}
+
+// isKey reports whether the string preceding b is an attribute name
+func isKey(b []byte) bool {
+	for _, c := range b {
+		switch c {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return c == ':'
+	}
+	return false
+}
+
+// unquote returns the string represented by the content of a JSON string
+func unquote(content []byte) string {
+	if bytes.IndexByte(content, '\\') < 0 {
+		return string(content)
+	}
+
+	var s string
+	if err := json.Unmarshal(append(append([]byte{'"'}, content...), '"'), &s); err != nil {
+		return string(content)
+	}
+	return s
+}
+
+// truncate returns the content of a JSON string shortened to at most n bytes,
+// without splitting an escape sequence or UTF-8 encoded rune
+func truncate(content []byte, n int) []byte {
+	end := 0
+	for i := 0; i < len(content); {
+		size := 1
+		switch {
+		case content[i] == '\\' && i+1 < len(content) && content[i+1] == 'u':
+			size = 6
+		case content[i] == '\\':
+			size = 2
+		case content[i] >= utf8.RuneSelf:
+			_, size = utf8.DecodeRune(content[i:])
+		}
+		if i+size > n {
+			break
+		}
+		i += size
+		end = i
+	}
+	return content[:end]
+}
@@ -0,0 +1,95 @@
+package unpack
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type article struct {
+	name    string
+	Title   string            `json:"title"`
+	Body    string            `json:"body"`
+	Tags    []string          `json:"tags"`
+	Authors map[string]string `json:"authors"`
+}
+
+func (a *article) SetName(name string) {
+	a.name = name
+}
+
+type articlef struct{}
+
+func (f articlef) New() Unpackable {
+	return new(article)
+}
+
+func TestTruncate(t *testing.T) {
+
+	type tc struct {
+		content string
+		n       int
+		result  string
+	}
+
+	tests := []tc{
+		{content: `abcdef`, n: 3, result: `abc`},
+		{content: `ab\ncd`, n: 3, result: `ab`},
+		{content: `ab\ncd`, n: 4, result: `ab\n`},
+		{content: `a\u00e9b`, n: 6, result: `a`},
+		{content: `a\u00e9b`, n: 7, result: `a\u00e9`},
+		{content: `aéb`, n: 2, result: `a`},
+		{content: `aéb`, n: 3, result: `aé`},
+		{content: `abc`, n: 0, result: ``},
+	}
+
+	for i, test := range tests {
+		assert.Equal(t, test.result, string(truncate([]byte(test.content), test.n)), "test %d", i)
+	}
+}
+
+func TestUnpackMaxValueLength(t *testing.T) {
+
+	b := []byte(`
+{
+	"articles": {
+		"a1": {
+			"title": "Short",
+			"body": "` + strings.Repeat("x", 100) + `",
+			"tags": ["go", "` + strings.Repeat("y", 20) + `"],
+			"authors": { "lead": "Ann \"The Editor\" Smith" }
+		}
+	}
+}
+	`)
+
+	_, err := Unpack(b, articlef{}, WithMaxValueLength(10))
+	var le *LimitError
+	assert.True(t, errors.As(err, &le))
+	assert.Equal(t, &LimitError{Limit: "value length", Max: 10, Actual: 100}, le)
+	var ue *UnpackError
+	assert.True(t, errors.As(err, &ue))
+	assert.Equal(t, "a1", ue.Name)
+	assert.Equal(t, "body", ue.Path)
+
+	_, err = Unpack(b, articlef{}, WithMaxValueLength(10), WithFieldMaxValueLength("body", 0))
+	assert.True(t, errors.As(err, &ue))
+	assert.Equal(t, "tags", ue.Path)
+
+	u, err := Unpack(b, articlef{}, WithMaxValueLength(10), WithFieldMaxValueLength("body", 0), WithTruncateValues())
+	assert.Nil(t, err)
+	assert.Equal(t, &article{
+		name:    "a1",
+		Title:   "Short",
+		Body:    strings.Repeat("x", 100),
+		Tags:    []string{"go", strings.Repeat("y", 10)},
+		Authors: map[string]string{"lead": `Ann "The `},
+	}, u[0])
+
+	u, err = Unpack(b, articlef{}, WithMaxValueLength(200), WithFieldMaxValueLength("body", 50), WithTruncateValues())
+	assert.Nil(t, err)
+	assert.Equal(t, strings.Repeat("x", 50), u[0].(*article).Body)
+	assert.Equal(t, []string{"go", strings.Repeat("y", 20)}, u[0].(*article).Tags)
+}
@@ -0,0 +1,66 @@
+package unpack
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// weaken returns the JSON value converted, where possible, to the form that
+// encoding/json expects for a field of type t: numbers and booleans held as
+// strings are unquoted, numbers and booleans are quoted for string fields,
+// and numbers are converted to booleans for bool fields.  Values that
+// cannot be converted are returned unchanged.
+func weaken(raw json.RawMessage, t reflect.Type) json.RawMessage {
+
+	t = indirect(t)
+
+	if len(raw) == 0 || string(raw) == "null" || reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		return raw
+	}
+
+	var s string
+	quoted := raw[0] == '"'
+	if quoted {
+		if json.Unmarshal(raw, &s) != nil {
+			return raw
+		}
+		s = strings.TrimSpace(s)
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		if !quoted && (raw[0] == '-' || isDigit(raw[0]) || string(raw) == "true" || string(raw) == "false") {
+			return json.RawMessage(strconv.Quote(string(raw)))
+		}
+	case reflect.Bool:
+		switch {
+		case quoted:
+			if b, err := strconv.ParseBool(s); err == nil {
+				return json.RawMessage(strconv.FormatBool(b))
+			}
+		case raw[0] == '-' || isDigit(raw[0]):
+			if f, err := strconv.ParseFloat(string(raw), 64); err == nil {
+				return json.RawMessage(strconv.FormatBool(f != 0))
+			}
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		switch {
+		case quoted:
+			if isNumber(s) && json.Valid([]byte(s)) {
+				return json.RawMessage(s)
+			}
+		case string(raw) == "true":
+			return json.RawMessage("1")
+		case string(raw) == "false":
+			return json.RawMessage("0")
+		}
+	}
+
+	return raw
+}